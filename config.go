@@ -5,6 +5,7 @@ import (
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/google/uuid"
 	"log/slog"
+	"time"
 )
 
 type Config struct {
@@ -15,20 +16,126 @@ type Config struct {
 	PlayerHandler player.Handler
 	WorldHandler  world.Handler
 	PlayAgainHook func(p *player.Player)
+	// Maps is the MapRegistry that maps of the game are loaded from. It should be shared by all games of a mode so
+	// that maps are only loaded once. If nil, the registry of the Factory that creates the game is used, or maps are
+	// loaded from MapsDir for games created without a Factory.
+	Maps *MapRegistry
+	// WorldInstancing specifies how the world of the selected map is instanced for the game. Defaults to
+	// InstanceMemory.
+	WorldInstancing WorldInstancing
+	// WaitingWorld is the world that participants of the game wait in before it starts. If nil, the game uses
+	// the waiting world of its Factory or the DefaultWaitingWorld.
+	WaitingWorld *world.World
+	// WaitingMap is a map whose world is instanced in memory as a waiting world of the game's own, which is closed
+	// when the game closes. It is ignored if WaitingWorld is set.
+	WaitingMap *Map
+	// LobbyMap is the name of a lobby map in the maps directory that is used as WaitingMap. It is ignored if
+	// WaitingWorld or WaitingMap is set.
+	LobbyMap string
+	// HotbarItems are items put in the hotbar of participants in addition to the items of the game itself. Items
+	// with the ID of an item of the game replace that item.
+	HotbarItems []HotbarItem
+	// Kits are the kits that participants are able to select, in addition to the kits defined in the kits.yml file
+	// of the maps directory. Kits with the name of a kit in kits.yml replace that kit.
+	Kits []*Kit
+	// DefaultKit is the name of the kit that participants receive if they did not select a kit. If empty, such
+	// participants receive no kit.
+	DefaultKit string
+	// KitPermission returns whether the player passed has the permission passed, which is needed to select kits
+	// with a Permission. If nil, kits with a Permission are locked for all players.
+	KitPermission func(p *player.Player, permission string) bool
+	// KitStore is the store that the kits selected by players are remembered in. If nil, DefaultKitStore is used,
+	// which forgets selections on restart. Use a JSONKitStore to remember selections across restarts.
+	KitStore KitStore
+	// MapTags are the tags that maps in MapsDir must all have to be played in the game. If empty, all maps are
+	// played.
+	MapTags []string
+	// Teams are the teams that participants of the game are split into. If empty, the game has no teams.
+	Teams []TeamConfig
+	// TeamSelector specifies whether participants receive an item in the waiting State to choose their own team.
+	TeamSelector bool
+	// FriendlyFire specifies whether participants in the same team are able to attack each other.
+	FriendlyFire bool
+	// ReconnectGrace is the duration that a participant that disconnects while the game is playing is kept in the
+	// game, so that it may rejoin. If 0, participants leave the game immediately when they disconnect.
+	ReconnectGrace time.Duration
+	// AutoRejoin specifies whether players are moved back into their game directly in HandleRejoin instead of
+	// being asked whether they want to rejoin.
+	AutoRejoin bool
+	// Mode is the name of the game mode of the game, for example "bedwars". It is used to keep statistics of
+	// different game modes apart.
+	Mode string
+	// Stats is the store that statistics of participants are recorded in. If nil, no statistics are recorded.
+	Stats StatsStore
+	// Elo is the configuration of the Elo ratings of participants. If nil, participants are not rated. Ratings are
+	// stored in Stats, which must be set if Elo is set.
+	Elo *EloConfig
+	// MapSelector selects the map that the game is played on. The same selector should be shared by all games of
+	// a mode so that it can keep track of the maps played. If nil, VoteSelector is used.
+	MapSelector MapSelector
+	// BallotSize is the amount of maps that players are able to vote for in a game, chosen randomly from all maps
+	// when the game is created. If 0, players are able to vote for all maps.
+	BallotSize int
+	// WinConditions are the conditions under which the game ends, evaluated in order. Regardless of the
+	// conditions, the game ends when no participant is left playing.
+	WinConditions []WinCondition
+	// Respawn is the configuration of the death handling of the game. If nil, participants die and respawn as
+	// usual, and deaths are left to the PlayerHandler of the game.
+	Respawn *RespawnConfig
+	// KillCreditWindow is how long after a participant was hurt by another participant that its death is credited
+	// to that participant, even if it died by another cause such as the void. Defaults to 10 seconds.
+	KillCreditWindow time.Duration
+	// Lifecycle holds the timings of the lifecycle of the game.
+	Lifecycle Lifecycle
+
+	// factory is the Factory that the game is created by, if any.
+	factory *Factory
+	// pinnedMap is the name of the map that the game is pinned to, if any.
+	pinnedMap string
 }
 
+// DefaultWaitingWorld is the lobby that players are returned to when they leave a game. It is also the waiting
+// world of games that have no waiting world of their own.
 var DefaultWaitingWorld *world.World
 
 func (c *Config) New() (*Game, error) {
 	g := &Game{
-		log:           c.Log,
-		id:            c.ID,
-		mDir:          c.MapsDir,
-		impl:          c.Impl,
-		ph:            c.PlayerHandler,
-		wh:            c.WorldHandler,
-		playAgainHook: c.PlayAgainHook,
+		log:            c.Log,
+		id:             c.ID,
+		mDir:           c.MapsDir,
+		mTags:          c.MapTags,
+		registry:       c.Maps,
+		instancing:     c.WorldInstancing,
+		waitingMap:     c.WaitingMap,
+		lobbyMapName:   c.LobbyMap,
+		hotbarItems:    c.HotbarItems,
+		kitConfs:       c.Kits,
+		defaultKit:     c.DefaultKit,
+		kitPermission:  c.KitPermission,
+		kitStore:       c.KitStore,
+		impl:           c.Impl,
+		ph:             c.PlayerHandler,
+		wh:             c.WorldHandler,
+		playAgainHook:  c.PlayAgainHook,
+		teamConfs:      c.Teams,
+		teamSelector:   c.TeamSelector,
+		friendlyFire:   c.FriendlyFire,
+		reconnectGrace: c.ReconnectGrace,
+		autoRejoin:     c.AutoRejoin,
+		lifecycle:      c.Lifecycle,
+		respawn:        c.Respawn,
+		winConditions:  c.WinConditions,
+		creditWindow:   c.KillCreditWindow,
+		events:         NewEventBus(),
+		mode:           c.Mode,
+		stats:          c.Stats,
+		elo:            c.Elo,
+		mapSelector:    c.MapSelector,
+		ballotSize:     c.BallotSize,
+		factory:        c.factory,
+		pinName:        c.pinnedMap,
 	}
+	g.waiting.Store(c.WaitingWorld)
 	if err := g.Load(); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,104 @@
+package game
+
+import (
+	"errors"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/title"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/sound"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"slices"
+	"time"
+)
+
+// countdownAnnouncements are the amounts of seconds left in the countdown at which the countdown is announced.
+var countdownAnnouncements = []int{10, 5, 4, 3, 2, 1}
+
+// CountdownAnnouncer may be implemented by an Impl to replace the default announcement of the countdown in the
+// waiting State, which consists of a chat message, a title and a sound.
+type CountdownAnnouncer interface {
+	// AnnounceCountdown is called for every player in the game when the game starts in 10, 5, 4, 3, 2 and 1
+	// seconds.
+	AnnounceCountdown(p *player.Player, startingIn int)
+}
+
+// ForceStart starts the game as soon as its map is loaded, even if the minimum amount of players has not been
+// reached.
+func (g *Game) ForceStart(tx *world.Tx) error {
+	if !g.ValidTx(tx) {
+		return ErrInvalidTx
+	}
+	if !g.State().Waiting() {
+		return ErrNotWaiting
+	}
+	if g.participants.Len() == 0 {
+		return errors.New("game has no participants")
+	}
+	g.Start(tx)
+	return nil
+}
+
+// PauseCountdown pauses or resumes the countdown of the game in the waiting State. While paused, the game does
+// not start.
+func (g *Game) PauseCountdown(tx *world.Tx, paused bool) error {
+	if !g.ValidTx(tx) {
+		return ErrInvalidTx
+	}
+	if !g.State().Waiting() {
+		return ErrNotWaiting
+	}
+	g.countdownPaused = paused
+	return nil
+}
+
+// CountdownPaused returns whether the countdown of the game is paused.
+func (g *Game) CountdownPaused() bool {
+	return g.countdownPaused
+}
+
+// SetCountdown sets the time left until the game starts. The countdown is still reset if the amount of players
+// drops below the minimum.
+func (g *Game) SetCountdown(tx *world.Tx, d time.Duration) error {
+	if !g.ValidTx(tx) {
+		return ErrInvalidTx
+	}
+	if !g.State().Waiting() {
+		return ErrNotWaiting
+	}
+	if d <= 0 {
+		return errors.New("countdown must be positive")
+	}
+	g.startingIn = int(d.Seconds())
+	return nil
+}
+
+// shortenCountdown shortens the countdown if the game reached the fill ratio or maximum amount of players
+// configured in its lifecycle.
+func (g *Game) shortenCountdown() {
+	n, max := g.participants.Len(), g.MaxPlayers()
+	if full := int(g.lifecycle.FullCountdown.Seconds()); full > 0 && n >= max && g.startingIn > full {
+		g.startingIn = full
+	}
+	if fill := int(g.lifecycle.FillCountdown.Seconds()); fill > 0 && g.lifecycle.FillRatio > 0 && max > 0 &&
+		float64(n)/float64(max) >= g.lifecycle.FillRatio && g.startingIn > fill {
+		g.startingIn = fill
+	}
+}
+
+// announceCountdown announces the countdown to all players in the game if the amount of seconds left is one of
+// the countdown announcements.
+func (g *Game) announceCountdown(tx *world.Tx) {
+	if !slices.Contains(countdownAnnouncements, g.startingIn) {
+		return
+	}
+	announcer, custom := g.impl.(CountdownAnnouncer)
+	g.Players(tx, func(p *player.Player, _ *Participant) {
+		if custom {
+			announcer.AnnounceCountdown(p, g.startingIn)
+			return
+		}
+		p.Message(text.Colourf("<yellow>The game starts in <red>%d</red> second(s)!</yellow>", g.startingIn))
+		p.SendTitle(title.New(text.Colourf("<red>%d</red>", g.startingIn)).WithFadeInDuration(0).WithDuration(time.Second))
+		p.PlaySound(sound.Click{})
+	})
+}
@@ -0,0 +1,120 @@
+package game
+
+import (
+	"errors"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"runtime/debug"
+)
+
+var (
+	// ErrGameClosed is returned when a game that was already closed is used.
+	ErrGameClosed = errors.New("game is closed")
+	// ErrNotWaiting is returned when an action that is only possible in the waiting State is done in another
+	// State.
+	ErrNotWaiting = errors.New("game is not in waiting State")
+	// ErrNotPlaying is returned when an action that is only possible in the playing State is done in another
+	// State.
+	ErrNotPlaying = errors.New("game is not in playing State")
+	// ErrGameFull is returned when a player joins a game that reached its maximum amount of players.
+	ErrGameFull = errors.New("game is full")
+	// ErrInvalidTx is returned when a transaction is passed that is not a transaction of the world of the game.
+	ErrInvalidTx = errors.New("expected transaction to be valid")
+	// ErrSessionNotFound is returned when the session of a player could not be found.
+	ErrSessionNotFound = errors.New("player session not found")
+	// ErrAlreadyInGame is returned when a player that is already in a game joins a game.
+	ErrAlreadyInGame = errors.New("player is already in a game")
+	// ErrNotInGame is returned when a player leaves a game that it is not in.
+	ErrNotInGame = errors.New("player is not in the game")
+	// ErrNotAllowed is returned when the Impl of a game does not allow a player to join.
+	ErrNotAllowed = errors.New("player not allowed to join")
+)
+
+// Abort tears down the game with the reason passed, regardless of its State. All participants leave the game and
+// are returned to the waiting world, without their game being recorded in their statistics, after which the game
+// is closed.
+func (g *Game) Abort(reason string) {
+	if g.closed.Load() {
+		return
+	}
+	w := g.world()
+	if w == nil {
+		return
+	}
+	w.Exec(func(tx *world.Tx) {
+		g.abort(tx, reason)
+	})
+}
+
+// abort aborts the game with the reason passed. A panic while aborting is recovered, after which the remaining
+// players are moved to the lobby and the game is closed without notifying the Impl any further.
+func (g *Game) abort(tx *world.Tx, reason string) {
+	if g.closed.Load() || g.aborting || !g.ValidTx(tx) {
+		return
+	}
+	g.aborting = true
+	defer func() {
+		if r := recover(); r != nil {
+			g.log.Error("recovered from panic while aborting game", "panic", r, "stack", string(debug.Stack()))
+			g.evict(tx)
+			g.release(tx)
+		}
+	}()
+
+	g.log.Warn("game aborted", "reason", reason)
+	g.events.publish(GameAborted{Game: g, Tx: tx, Reason: reason})
+	g.Players(tx, func(p *player.Player, par *Participant) {
+		p.Message(text.Colourf("<red>The game was aborted: %s</red>", reason))
+		if _, err := g.Leave(p); err != nil {
+			g.log.Error("failed to remove player from aborted game", "name", p.Name(), "error", err)
+		}
+	})
+	g.close(tx)
+}
+
+// callImpl calls the function passed, which calls into the Impl of the game, aborting the game if it panics.
+func (g *Game) callImpl(tx *world.Tx, f func()) {
+	defer g.recoverImpl(tx)
+	f()
+}
+
+// recoverImpl recovers from a panic in a callback of the Impl of the game, aborting the game instead of crashing
+// the process. It must be deferred.
+func (g *Game) recoverImpl(tx *world.Tx) {
+	if r := recover(); r != nil {
+		g.handlePanic(tx, r)
+	}
+}
+
+// handlePanic logs the recovered panic passed and aborts the game. If the transaction passed is not a transaction of
+// the world of the game, the game is aborted in its own world instead.
+func (g *Game) handlePanic(tx *world.Tx, r any) {
+	g.log.Error("recovered from panic in game", "panic", r, "stack", string(debug.Stack()))
+	if tx == nil || !g.ValidTx(tx) {
+		g.Abort(panicReason)
+		return
+	}
+	g.abort(tx, panicReason)
+}
+
+// panicReason is the reason that games are aborted with after a panic.
+const panicReason = "an internal error occurred"
+
+// evict moves all players in the game to the lobby without notifying the Impl. It is used to tear down a game whose
+// Impl cannot be trusted anymore.
+func (g *Game) evict(tx *world.Tx) {
+	g.Players(tx, func(p *player.Player, par *Participant) {
+		if sess, ok := globalSessionManager.Load(p.XUID()); ok {
+			sess.SetGame(nil)
+		}
+		if t, ok := par.Team(); ok {
+			t.remove(par)
+		}
+		g.participants.Delete(par.xuid)
+		resetPlayer(p)
+		if p.Tx().World() != DefaultWaitingWorld {
+			moveToLobby(p)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"github.com/df-mc/dragonfly/server/world"
+	"slices"
+	"sync"
+)
+
+// Event is an event in the lifecycle of a game that may be observed through an EventBus.
+type Event interface {
+	event()
+}
+
+// GameCreated is published when a game is created by a Factory.
+type GameCreated struct {
+	Game *Game
+}
+
+// PlayerJoined is published when a player joined a game, after the Impl handled the join.
+type PlayerJoined struct {
+	Game        *Game
+	Tx          *world.Tx
+	Participant *Participant
+}
+
+// PlayerLeft is published when a participant left a game, after the Impl handled the quit.
+type PlayerLeft struct {
+	Game        *Game
+	Tx          *world.Tx
+	Participant *Participant
+}
+
+// StateChanged is published when the State of a game changed.
+type StateChanged struct {
+	Game     *Game
+	Old, New State
+}
+
+// MapSelected is published when the map of a game was selected and loaded.
+type MapSelected struct {
+	Game *Game
+	Tx   *world.Tx
+	Map  *Map
+}
+
+// GameEnded is published when a game ended with a result, after its State changed to StateFinished.
+type GameEnded struct {
+	Game   *Game
+	Tx     *world.Tx
+	Result Result
+}
+
+// ParticipantDied is published when a playing participant died. Killer is the participant credited with the
+// kill, or nil if nobody is.
+type ParticipantDied struct {
+	Game   *Game
+	Tx     *world.Tx
+	Victim *Participant
+	Killer *Participant
+}
+
+// GameAborted is published when a game was aborted, before it is closed.
+type GameAborted struct {
+	Game   *Game
+	Tx     *world.Tx
+	Reason string
+}
+
+// GameClosed is published when a game was closed and all players have left it.
+type GameClosed struct {
+	Game *Game
+	Tx   *world.Tx
+}
+
+func (GameCreated) event()     {}
+func (PlayerJoined) event()    {}
+func (PlayerLeft) event()      {}
+func (StateChanged) event()    {}
+func (MapSelected) event()     {}
+func (GameEnded) event()       {}
+func (ParticipantDied) event() {}
+func (GameAborted) event()     {}
+func (GameClosed) event()      {}
+
+// EventBus delivers events to its subscribers. Events are delivered synchronously in the order that they happen,
+// so subscribers observe them in the same order as the state transitions of the game. Subscribers are called in
+// the order that they subscribed. Events published on a bus are also published on its parent, if any.
+type EventBus struct {
+	mu     sync.Mutex
+	nextID uint64
+	subs   []subscriber
+
+	parent *EventBus
+}
+
+// subscriber is a function subscribed to an EventBus.
+type subscriber struct {
+	id uint64
+	fn func(e Event)
+}
+
+// NewEventBus creates a new EventBus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe subscribes the function passed to all events published on the bus. The function returned
+// unsubscribes it again.
+func (b *EventBus) Subscribe(fn func(e Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subs = append(b.subs, subscriber{id: id, fn: fn})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.subs = slices.DeleteFunc(b.subs, func(s subscriber) bool {
+			return s.id == id
+		})
+	}
+}
+
+// Subscribe subscribes the function passed to all events of type E published on the bus passed. The function
+// returned unsubscribes it again.
+func Subscribe[E Event](b *EventBus, fn func(e E)) (unsubscribe func()) {
+	return b.Subscribe(func(e Event) {
+		if e, ok := e.(E); ok {
+			fn(e)
+		}
+	})
+}
+
+// publish delivers the event passed to all subscribers of the bus and its parent.
+func (b *EventBus) publish(e Event) {
+	b.mu.Lock()
+	subs := slices.Clone(b.subs)
+	parent := b.parent
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		s.fn(e)
+	}
+	if parent != nil {
+		parent.publish(e)
+	}
+}
+
+// setParent sets the parent of the bus that events are forwarded to.
+func (b *EventBus) setParent(parent *EventBus) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.parent = parent
+}
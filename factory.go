@@ -1,30 +1,118 @@
 package game
 
 import (
+	"errors"
 	"fmt"
 	"github.com/akmalfairuz/df-game/internal"
 	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
 	"github.com/google/uuid"
 	"iter"
+	"log/slog"
+	"sync"
 )
 
 // Factory is a factory for games.
 type Factory struct {
-	games      *internal.Map[uuid.UUID, *Game]
-	createFunc func() *Game
+	games     *internal.Map[uuid.UUID, *Game]
+	newConfig func() Config
+
+	partySameTeam bool
+	matchmaking   MatchmakingStrategy
+
+	events *EventBus
+
+	leaderboards []*Leaderboard
+	records      *leaderboardRecords
+
+	regMu        sync.Mutex
+	registry     *MapRegistry
+	ownsRegistry bool
+	waiting      *world.World
+
+	log *slog.Logger
 }
 
 // FactoryConfig is a configuration for a game factory.
 type FactoryConfig struct {
-	CreateFunc func() *Game
+	// NewConfig returns the Config that a new game of the factory is created with. The MapRegistry and waiting
+	// world of the factory are used by the game if its Config has none of its own.
+	NewConfig func() Config
+	// PartySameTeam specifies whether members of a party that join a game through JoinParty are placed in the same
+	// team, if the game has teams.
+	PartySameTeam bool
+	// Matchmaking is the strategy used to decide which game players join. If nil, FirstFit is used.
+	Matchmaking MatchmakingStrategy
+	// Leaderboards are the leaderboards of the games created by the factory, shown in the DefaultWaitingWorld.
+	Leaderboards []LeaderboardConfig
+	// Stats is the StatsStore that the games created by the factory record statistics in. If set, all-time
+	// leaderboards are ranked from the stats of Mode in the store, so that they persist across restarts. If nil,
+	// all-time leaderboards only cover games played since the factory was created.
+	Stats StatsStore
+	// Mode is the game mode that all-time leaderboards are ranked by in Stats.
+	Mode string
+	// Maps is the MapRegistry shared by the games created by the factory. It is set in the Config of games that
+	// have no registry of their own. If nil, the factory creates a registry for the MapsDir of the first game it
+	// creates and shares it with all of its games, closing it when the factory is closed.
+	Maps *MapRegistry
+	// WaitingWorld is the waiting world of the games created by the factory that have no waiting world of their
+	// own. Leaderboards of the factory are shown in it. If nil, the DefaultWaitingWorld is used.
+	WaitingWorld *world.World
+	// Log is the logger that errors of the factory are logged to. If nil, slog.Default is used.
+	Log *slog.Logger
 }
 
 // New creates a new game factory.
 func (c FactoryConfig) New() *Factory {
-	return &Factory{
-		games:      internal.NewMap[uuid.UUID, *Game](),
-		createFunc: c.CreateFunc,
+	f := &Factory{
+		games:         internal.NewMap[uuid.UUID, *Game](),
+		newConfig:     c.NewConfig,
+		partySameTeam: c.PartySameTeam,
+		matchmaking:   c.Matchmaking,
+		events:        NewEventBus(),
+		registry:      c.Maps,
+		waiting:       c.WaitingWorld,
+		log:           c.Log,
+	}
+	if f.log == nil {
+		f.log = slog.Default()
 	}
+	if f.matchmaking == nil {
+		f.matchmaking = FirstFit{}
+	}
+	if len(c.Leaderboards) > 0 {
+		f.records = newLeaderboardRecords(f.events, c.Stats, c.Mode, f.log)
+		for _, conf := range c.Leaderboards {
+			f.leaderboards = append(f.leaderboards, newLeaderboard(conf, f.records, c.WaitingWorld))
+		}
+	}
+	return f
+}
+
+// Events returns the EventBus of the factory. All events of games created by the factory are also published on it.
+func (f *Factory) Events() *EventBus {
+	return f.events
+}
+
+// Close closes the leaderboards of the factory, removing them from the waiting world, and the MapRegistry that
+// the factory created, if any. Games of the factory are not closed.
+func (f *Factory) Close() {
+	for _, lb := range f.leaderboards {
+		lb.Close()
+	}
+	if f.records != nil {
+		f.records.close()
+	}
+	f.regMu.Lock()
+	defer f.regMu.Unlock()
+	if f.ownsRegistry {
+		f.registry.Close()
+	}
+}
+
+// Leaderboards returns the leaderboards of the factory.
+func (f *Factory) Leaderboards() []*Leaderboard {
+	return f.leaderboards
 }
 
 // Games returns available games.
@@ -38,28 +126,223 @@ func (f *Factory) Games() iter.Seq[*Game] {
 	}
 }
 
-// Join joins a player to a game.
-func (f *Factory) Join(p *player.Player) (*Game, bool) {
+// Maps returns the maps of the factory that have all the tags passed. If the factory has no MapRegistry, the maps
+// of its games are returned, where maps with the same name are only returned once.
+func (f *Factory) Maps(tags ...string) []*Map {
+	var maps []*Map
+	f.regMu.Lock()
+	reg := f.registry
+	f.regMu.Unlock()
+	if reg != nil {
+		for _, m := range reg.Maps() {
+			if m.WaitingRoom == nil && m.HasTags(tags...) {
+				maps = append(maps, m)
+			}
+		}
+		return maps
+	}
+	seen := make(map[string]struct{})
 	for g := range f.Games() {
-		if err := g.Join(p); err == nil {
-			return g, true
+		for _, m := range g.Maps(tags...) {
+			if _, ok := seen[m.Name]; !ok {
+				seen[m.Name] = struct{}{}
+				maps = append(maps, m)
+			}
+		}
+	}
+	return maps
+}
+
+// Join joins a player to a game chosen by the matchmaking strategy of the factory.
+func (f *Factory) Join(p *player.Player) (*Game, bool) {
+	return f.join(p, MatchRequest{XUIDs: []string{p.XUID()}})
+}
+
+// JoinMap joins a player to a game that is played on the map with the name passed, creating a new game pinned to
+// the map if needed. Only the MapQueue strategy keeps games of different maps apart; other strategies may place
+// the player in any game.
+func (f *Factory) JoinMap(p *player.Player, mapName string) (*Game, bool) {
+	return f.join(p, MatchRequest{XUIDs: []string{p.XUID()}, Map: mapName})
+}
+
+// join joins a player to a game according to the request passed.
+func (f *Factory) join(p *player.Player, req MatchRequest) (*Game, bool) {
+	for _, l := range f.rank(req) {
+		if err := l.Game.Join(p); err == nil {
+			return l.Game, true
 		}
 	}
 
-	newG := f.NewGame()
+	newG, err := f.newGameFor(req)
+	if err != nil {
+		f.log.Error("failed to create game for player", "player", p.Name(), "error", err)
+		return nil, false
+	}
 	if err := newG.Join(p); err != nil {
-		fmt.Println(err)
+		f.log.Error("failed to join new game", "player", p.Name(), "error", err)
 		return nil, false
 	}
 	return newG, true
 }
 
-// NewGame creates a new game.
-func (f *Factory) NewGame() *Game {
-	g := (f.createFunc)()
+// rank returns the lobbies of the factory that have room for the request passed, ordered by the matchmaking
+// strategy of the factory.
+func (f *Factory) rank(req MatchRequest) []Lobby {
+	lobbies := make([]Lobby, 0, f.games.Len())
+	for g := range f.Games() {
+		if g.closed.Load() || !g.State().Waiting() {
+			continue
+		}
+		if l := g.lobby(); l.Free() >= req.Size() {
+			lobbies = append(lobbies, l)
+		}
+	}
+	return f.matchmaking.Rank(req, lobbies)
+}
+
+// newGameFor creates a new game for the request passed, pinning it to the map of the request if it has one. No game
+// is created if the map does not exist.
+func (f *Factory) newGameFor(req MatchRequest) (*Game, error) {
+	return f.newGame(req.Map)
+}
+
+// JoinParty joins all members of the party passed to the same game, creating a new game if no existing game has
+// enough room for the whole party. All members must be in the world of the transaction passed. If any member
+// fails to join, the members that already joined leave the game again and an error is returned.
+func (f *Factory) JoinParty(tx *world.Tx, pt *Party) (*Game, error) {
+	if pt.Disbanded() {
+		return nil, errors.New("party is disbanded")
+	}
+
+	members := pt.Members()
+	players := make([]*player.Player, 0, len(members))
+	req := MatchRequest{XUIDs: make([]string, 0, len(members))}
+	for _, s := range members {
+		p, ok := s.Player(tx)
+		if !ok {
+			return nil, fmt.Errorf("party member %s is not available", s.Name())
+		}
+		if _, ok := s.Game(); ok {
+			return nil, fmt.Errorf("party member %s is already in a game", s.Name())
+		}
+		players = append(players, p)
+		req.XUIDs = append(req.XUIDs, p.XUID())
+	}
+
+	for _, l := range f.rank(req) {
+		if err := f.joinAll(l.Game, players); err == nil {
+			return l.Game, nil
+		}
+	}
+
+	newG, err := f.newGameFor(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.joinAll(newG, players); err != nil {
+		return nil, fmt.Errorf("failed to join party to new game: %w", err)
+	}
+	return newG, nil
+}
+
+// joinAll joins all players passed to the game passed. It first checks that all players are able to join and fit
+// in the game, and in a single team if the party should be kept together, so that either all or none of them join.
+// If one of the players still fails to join, the players that already joined are removed from the game again.
+func (f *Factory) joinAll(g *Game, players []*player.Player) error {
+	for _, p := range players {
+		if err := g.canJoin(p); err != nil {
+			return fmt.Errorf("player %s cannot join: %w", p.Name(), err)
+		}
+	}
+	if g.participants.Len()+len(players) > g.MaxPlayers() {
+		return ErrGameFull
+	}
+	together := f.partySameTeam && len(g.teams) > 0
+	if together && g.teamFor(len(players)) == nil {
+		return errors.New("no team has room for the whole party")
+	}
+
+	pars := make([]*Participant, 0, len(players))
+	pending := make([]bool, 0, len(players))
+	rollback := func() {
+		for i, pend := range pending {
+			g.cancelJoin(players[i], pend)
+		}
+	}
+	for _, p := range players {
+		pend, err := g.join(p)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("player %s failed to join: %w", p.Name(), err)
+		}
+		pending = append(pending, pend)
+		par, _ := g.ParticipantByXUID(p.XUID())
+		pars = append(pars, par)
+	}
+
+	if together && !g.placeTogether(pars) {
+		rollback()
+		return errors.New("no team has room for the whole party")
+	}
+	return nil
+}
+
+// NewGame creates a new game with the Config returned by NewConfig of the FactoryConfig.
+func (f *Factory) NewGame() (*Game, error) {
+	return f.newGame("")
+}
+
+// newGame creates a new game pinned to the map with the name passed, or a game that is not pinned if the name is
+// empty.
+func (f *Factory) newGame(pinnedMap string) (*Game, error) {
+	conf := (f.newConfig)()
+	conf.pinnedMap = pinnedMap
+	if conf.Maps == nil {
+		reg, err := f.mapRegistry(conf.MapsDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load maps: %w", err)
+		}
+		conf.Maps = reg
+	}
+	if conf.WaitingWorld == nil && conf.WaitingMap == nil && conf.LobbyMap == "" {
+		conf.WaitingWorld = f.waiting
+	}
+	conf.factory = f
+	g, err := conf.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create game: %w", err)
+	}
+	return g, nil
+}
+
+// mapRegistry returns the MapRegistry of the factory, creating it for the maps directory passed if the factory
+// has none yet. If the registry of the factory is for another directory, a registry of its own is returned for the
+// game, so that games with different maps directories keep working.
+func (f *Factory) mapRegistry(dir string) (*MapRegistry, error) {
+	f.regMu.Lock()
+	defer f.regMu.Unlock()
+	if f.registry != nil {
+		if f.ownsRegistry && f.registry.Dir() != dir {
+			return nil, nil
+		}
+		return f.registry, nil
+	}
+	reg, err := (MapRegistryConfig{Dir: dir, Log: f.log}).New()
+	if err != nil {
+		return nil, err
+	}
+	f.registry, f.ownsRegistry = reg, true
+	return reg, nil
+}
+
+// register adds the game passed to the games of the factory and publishes its events on the EventBus of the
+// factory. It is called while the game loads, before its first State transition, so that subscribers of the
+// factory receive every event of the game.
+func (f *Factory) register(g *Game) {
 	g.closeHook = func() {
 		f.games.Delete(g.ID())
 	}
+	g.events.setParent(f.events)
 	f.games.Store(g.ID(), g)
-	return g
+	f.events.publish(GameCreated{Game: g})
 }
@@ -1,21 +1,20 @@
 package game
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"github.com/akmalfairuz/df-game/internal"
 	"github.com/df-mc/dragonfly/server/entity"
-	"github.com/df-mc/dragonfly/server/item"
 	"github.com/df-mc/dragonfly/server/player"
 	"github.com/df-mc/dragonfly/server/world"
-	"github.com/df-mc/dragonfly/server/world/mcdb"
 	"github.com/google/uuid"
 	"github.com/sandertv/gophertunnel/minecraft/text"
 	"iter"
 	"log/slog"
-	"math/rand"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -35,6 +34,8 @@ type Game struct {
 
 	m             *Map
 	mDir          string
+	mTags         []string
+	registry      *MapRegistry
 	availableMaps []*Map
 
 	tickQueue chan struct{}
@@ -43,34 +44,76 @@ type Game struct {
 
 	closed atomic.Bool
 
-	startingIn  int
-	closingIn   int
-	currentTick atomic.Uint64
-
-	mapLoaded bool
-	wPath     string
+	startingIn      int
+	countdownPaused bool
+	closingIn       int
+	currentTick     atomic.Uint64
+	playingTicks    atomic.Uint64
+	lifecycle       Lifecycle
+
+	respawn      *RespawnConfig
+	creditWindow time.Duration
+
+	winConditions []WinCondition
+	eliminated    []*Participant
+
+	waiting      atomic.Pointer[world.World]
+	waitingMap   *Map
+	ownWaiting   bool
+	waitingReady chan struct{}
+
+	lobbyMapName string
+
+	hotbar      *HotbarRegistry
+	hotbarItems []HotbarItem
+
+	kits          []*Kit
+	kitConfs      []*Kit
+	defaultKit    string
+	kitPermission func(p *player.Player, permission string) bool
+	kitStore      KitStore
+
+	mapLoaded    bool
+	mapLoading   bool
+	startPending bool
+	aborting     bool
+	wPath        string
+	instancing   WorldInstancing
+	pinnedMap    *Map
+	selectedMap  *Map
+	pinName      string
+
+	mapSelector MapSelector
+	ballotSize  int
+	ballot      []*Map
 
 	playAgainHook func(p *player.Player)
 
+	teamConfs    []TeamConfig
+	teams        []*Team
+	teamSelector bool
+	friendlyFire bool
+
+	reconnectGrace time.Duration
+	autoRejoin     bool
+
+	factory   *Factory
 	closeHook func()
+	closing   bool
+
+	events *EventBus
+	result *Result
+
+	mode  string
+	stats StatsStore
+
+	elo     *EloConfig
+	leavers []ratedLeaver
 
 	ph player.Handler
 	wh world.Handler
 }
 
-var (
-	gameItemKey         = "gameItem"
-	quitItemValue       = "quit"
-	playAgainItemValue  = "playAgain"
-	teleporterItemValue = "teleporter"
-	voteMapItemValue    = "voteMap"
-
-	quitItem       = item.NewStack(item.DragonBreath{}, 1).WithCustomName(text.Colourf("<red>Quit</red>")).WithValue(gameItemKey, quitItemValue)
-	playAgainItem  = item.NewStack(item.Paper{}, 1).WithCustomName(text.Colourf("<green>Play Again</green>")).WithValue(gameItemKey, playAgainItemValue)
-	teleporterItem = item.NewStack(item.Compass{}, 1).WithCustomName(text.Colourf("<yellow>Teleporter</yellow>")).WithValue(gameItemKey, teleporterItemValue)
-	voteMapItem    = item.NewStack(item.Paper{}, 1).WithCustomName(text.Colourf("<yellow>Vote Map</yellow>")).WithValue(gameItemKey, voteMapItemValue)
-)
-
 // ID returns the ID of the game.
 func (g *Game) ID() uuid.UUID {
 	return g.id
@@ -78,22 +121,96 @@ func (g *Game) ID() uuid.UUID {
 
 // Load ...
 func (g *Game) Load() error {
-	maps, err := loadMaps(g.mDir)
-	if err != nil {
-		return fmt.Errorf("failed to load maps: %w", err)
+	if g.registry == nil {
+		reg, err := (MapRegistryConfig{Dir: g.mDir, Log: g.log}).New()
+		if err != nil {
+			return fmt.Errorf("failed to load maps: %w", err)
+		}
+		g.registry = reg
+	}
+	maps := slices.DeleteFunc(g.registry.Maps(), func(m *Map) bool {
+		if m.WaitingRoom != nil || !m.HasTags(g.mTags...) {
+			return true
+		}
+		if err := m.validateTeams(g.teamConfs); err != nil {
+			g.log.Error("map does not match teams of game", "map", m.Name, "error", err)
+			return true
+		}
+		if err := m.validateSpawns(cmp.Or(m.MaxPlayers, g.impl.MaxPlayers())); err != nil {
+			g.log.Error("map does not have enough spawns for game", "map", m.Name, "error", err)
+			return true
+		}
+		return false
+	})
+	if len(maps) == 0 {
+		return fmt.Errorf("no maps available in %s", g.registry.Dir())
+	}
+	if g.pinName != "" {
+		i := slices.IndexFunc(maps, func(m *Map) bool {
+			return m.Name == g.pinName
+		})
+		if i < 0 {
+			return fmt.Errorf("map %s not found", g.pinName)
+		}
+		g.pinnedMap = maps[i]
+	}
+
+	if g.elo != nil && g.stats == nil {
+		return errors.New("elo ratings require a stats store")
+	}
+
+	g.kits = mergeKits(g.registry.Kits(), g.kitConfs)
+	if g.kitStore == nil {
+		g.kitStore = DefaultKitStore
+	}
+	if _, ok := g.Kit(g.defaultKit); g.defaultKit != "" && !ok {
+		return fmt.Errorf("default kit %s not found", g.defaultKit)
+	}
+
+	if g.waiting.Load() == nil && g.waitingMap == nil && g.lobbyMapName != "" {
+		m, err := g.lobbyMap(g.lobbyMapName)
+		if err != nil {
+			return err
+		}
+		g.waitingMap = m
+	}
+	g.ownWaiting = g.waiting.Load() == nil && g.waitingMap != nil
+	g.waitingReady = nil
+	if g.ownWaiting {
+		g.waitingReady = make(chan struct{})
 	}
 
 	if g.id == uuid.Nil {
 		g.id = uuid.New()
 	}
+	if g.events == nil {
+		g.events = NewEventBus()
+	}
 	g.closed.Store(false)
+	g.closing = false
 	g.availableMaps = maps
 	g.participants = internal.NewMap[string, *Participant]()
+	g.teams = make([]*Team, 0, len(g.teamConfs))
+	for _, conf := range g.teamConfs {
+		g.teams = append(g.teams, conf.New())
+	}
 	g.tickQueue = make(chan struct{}, 32)
-	g.setState(StateWaiting)
+	g.lifecycle = g.lifecycle.withDefaults()
+	if g.creditWindow <= 0 {
+		g.creditWindow = defaultKillCreditWindow
+	}
+	if g.elo != nil {
+		elo := g.elo.withDefaults()
+		g.elo = &elo
+	}
+	g.leavers = nil
+	g.eliminated = nil
+	g.playingTicks.Store(0)
 	g.startingIn = int(g.impl.WaitingDuration().Seconds())
 	g.wPath = filepath.Join("game_worlds", g.id.String())
-	g.impl.Load()
+	if err := g.loadImpl(); err != nil {
+		return err
+	}
 
 	if g.wh == nil {
 		g.wh = world.NopHandler{}
@@ -101,22 +218,157 @@ func (g *Game) Load() error {
 	if g.ph == nil {
 		g.ph = player.NopHandler{}
 	}
+	if g.factory != nil {
+		g.factory.register(g)
+	}
+	g.setState(StateWaiting)
 
+	if g.ownWaiting {
+		go g.loadWaitingWorld()
+		return nil
+	}
 	go g.startTicking()
 	return nil
 }
 
+// loadWaitingWorld loads the world of the waiting map of the game on a background goroutine, so that creating the
+// game does not block the world that it is created from. Players that join the game in the meantime are moved to
+// the waiting world once it is ready. The game starts ticking once its waiting world is ready, and is aborted if
+// the world could not be loaded.
+func (g *Game) loadWaitingWorld() {
+	t, err := g.waitingMap.worldTemplate()
+	if err == nil {
+		g.waiting.Store(g.newInstanceWorld(t.instance()))
+	}
+	close(g.waitingReady)
+	if err != nil {
+		g.log.Error("failed to load waiting map", "map", g.waitingMap.Name, "error", err)
+		DefaultWaitingWorld.Exec(func(tx *world.Tx) {
+			g.abort(tx, "the waiting map could not be loaded")
+		})
+		return
+	}
+	g.startTicking()
+}
+
+// waitingLoading returns true if the waiting world of the game is still being loaded.
+func (g *Game) waitingLoading() bool {
+	if g.waitingReady == nil {
+		return false
+	}
+	select {
+	case <-g.waitingReady:
+		return false
+	default:
+		return true
+	}
+}
+
+// loadImpl loads the Impl of the game and registers its hotbar items. A panic in the Impl is returned as an error.
+func (g *Game) loadImpl() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to load game: %v", r)
+		}
+	}()
+	g.impl.Load()
+	g.hotbar = newHotbarRegistry()
+	for _, i := range g.hotbarItems {
+		g.hotbar.Register(i)
+	}
+	if provider, ok := g.impl.(HotbarItemProvider); ok {
+		for _, i := range provider.HotbarItems() {
+			g.hotbar.Register(i)
+		}
+	}
+	g.ballot = g.newBallot()
+	return nil
+}
+
 // MapLoaded returns whether the map is loaded.
 func (g *Game) MapLoaded() bool {
 	return g.mapLoaded
 }
 
-// Map returns the map that the game is currently using.
-func (g *Game) Map() *Map {
-	if g.m == nil {
-		panic("map not loaded")
+// Map returns the map that the game is currently using. If the map is not loaded yet, the second return value is
+// false.
+func (g *Game) Map() (*Map, bool) {
+	return g.m, g.m != nil
+}
+
+// MinPlayers returns the minimum amount of players required to start the game. If the map of the game has been
+// selected or pinned and overrides the minimum amount of players, the value of the map is returned.
+func (g *Game) MinPlayers() int {
+	if m := g.limitsMap(); m != nil && m.MinPlayers > 0 {
+		return m.MinPlayers
+	}
+	return g.impl.MinPlayers()
+}
+
+// MaxPlayers returns the maximum amount of players that can be in the game. If the map of the game has been
+// selected or pinned and overrides the maximum amount of players, the value of the map is returned.
+func (g *Game) MaxPlayers() int {
+	if m := g.limitsMap(); m != nil && m.MaxPlayers > 0 {
+		return m.MaxPlayers
+	}
+	return g.impl.MaxPlayers()
+}
+
+// limitsMap returns the map whose player limits apply to the game: the map that was selected, even if its world
+// is still loading, or the map that the game is pinned to. It returns nil if neither is known yet.
+func (g *Game) limitsMap() *Map {
+	if g.selectedMap != nil {
+		return g.selectedMap
+	}
+	return g.pinnedMap
+}
+
+// StartingIn returns the amount of seconds until the game starts, or -1 if the game is not counting down.
+func (g *Game) StartingIn() int {
+	if !g.State().Waiting() || g.participants.Len() < g.MinPlayers() {
+		return -1
+	}
+	return g.startingIn
+}
+
+// PinnedMap returns the map that the game was pinned to using PinMap, if any.
+func (g *Game) PinnedMap() (*Map, bool) {
+	return g.pinnedMap, g.pinnedMap != nil
+}
+
+// PinMap pins the game to the map with the name passed, so that the game is played on that map regardless of
+// votes. An error is returned if the map does not exist or the map was already selected.
+func (g *Game) PinMap(name string) error {
+	if g.mapLoaded || g.mapLoading {
+		return errors.New("map already loaded")
+	}
+	for _, m := range g.availableMaps {
+		if m.Name == name {
+			g.pinnedMap = m
+			return nil
+		}
+	}
+	return fmt.Errorf("map %s not found", name)
+}
+
+// lobby returns a snapshot of the game for matchmaking.
+func (g *Game) lobby() Lobby {
+	l := Lobby{
+		Game:       g,
+		ID:         g.id,
+		Players:    g.participants.Len(),
+		MinPlayers: g.MinPlayers(),
+		MaxPlayers: g.MaxPlayers(),
+		StartingIn: g.StartingIn(),
+		XUIDs:      make([]string, 0, g.participants.Len()),
+	}
+	if g.pinnedMap != nil {
+		l.PinnedMap = g.pinnedMap.Name
+	}
+	for xuid := range g.participants.Map() {
+		l.XUIDs = append(l.XUIDs, xuid)
 	}
-	return g.m
+	return l
 }
 
 // State returns the current State of the game.
@@ -129,13 +381,23 @@ func (g *Game) State() State {
 // setState sets the State of the game to the State passed.
 func (g *Game) setState(s State) {
 	g.sMu.Lock()
+	old := g.s
 	g.s = s
 	g.sMu.Unlock()
+
+	if old != s {
+		g.events.publish(StateChanged{Game: g, Old: old, New: s})
+	}
+}
+
+// Events returns the EventBus that events of the game are published on.
+func (g *Game) Events() *EventBus {
+	return g.events
 }
 
 // startTicking is used to start the game ticking. This function should be called in a goroutine.
 func (g *Game) startTicking() {
-	t := time.NewTicker(time.Second / 20)
+	t := time.NewTicker(time.Second / time.Duration(g.lifecycle.TickRate))
 	defer t.Stop()
 
 	for {
@@ -144,11 +406,18 @@ func (g *Game) startTicking() {
 			if g.closed.Load() {
 				return
 			}
-			g.world().Exec(g.onTick)
+			if w := g.world(); w != nil {
+				w.Exec(g.onTick)
+			}
 		}
 	}
 }
 
+// Mode returns the name of the game mode of the game.
+func (g *Game) Mode() string {
+	return g.mode
+}
+
 // Impl returns the implementation of the game.
 func (g *Game) Impl() Impl {
 	return g.impl
@@ -159,27 +428,30 @@ func (g *Game) onTick(tx *world.Tx) {
 	if g.closed.Load() || !g.ValidTx(tx) {
 		return
 	}
+	defer g.recoverImpl(tx)
 	g.currentTick.Add(1)
 	currentTick := g.currentTick.Load()
+	second := currentTick%uint64(g.lifecycle.TickRate) == 0
 
 	switch g.State() {
 	case StateWaiting:
-		if currentTick%20 != 0 {
+		if !second {
 			break
 		}
-		enoughPlayers := g.participants.Len() >= g.impl.MinPlayers()
-		if enoughPlayers {
+		enoughPlayers := g.participants.Len() >= g.MinPlayers()
+		if enoughPlayers && !g.countdownPaused {
 			g.startingIn--
-			if g.startingIn <= 4 && !g.mapLoaded {
-				if err := g.loadMap(tx); err != nil {
-					panic(err)
-				}
+			g.shortenCountdown()
+			g.announceCountdown(tx)
+			if g.startingIn <= int(g.lifecycle.MapPreload.Seconds()) {
+				g.prepareMap(tx)
 			}
 			if g.startingIn <= 0 {
+				g.startingIn = 0
 				g.Start(tx)
 			}
-		} else {
-			g.startingIn = int(g.impl.WaitingDuration().Seconds())
+		} else if !enoughPlayers {
+			g.resetCountdown()
 		}
 
 		participantLen := g.participants.Len()
@@ -191,9 +463,19 @@ func (g *Game) onTick(tx *world.Tx) {
 			g.impl.RenderWaitingScoreboard(p, s, participantLen)
 		})
 	case StatePlaying:
-		g.impl.HandlePlayingTick(tx, currentTick)
+		if second {
+			g.expireDisconnected(tx)
+			if g.checkWin(tx) {
+				break
+			}
+		}
+		g.tickLifecycle(tx)
+		g.tickRespawns(tx)
+		if g.State().Playing() {
+			g.impl.HandlePlayingTick(tx, currentTick)
+		}
 	case StateFinished:
-		if currentTick%20 != 0 {
+		if !second {
 			break
 		}
 		g.closingIn--
@@ -203,7 +485,7 @@ func (g *Game) onTick(tx *world.Tx) {
 		}
 
 		g.Players(tx, func(p *player.Player, par *Participant) {
-			g.impl.RenderFinishedScoreboard(p, g.closingIn)
+			g.impl.RenderFinishedScoreboard(p, g.closingIn, *g.result)
 		})
 	default: // unknown State
 	}
@@ -221,70 +503,175 @@ func (g *Game) ValidTx(tx *world.Tx) (valid bool) {
 	return tx.World() == g.world()
 }
 
-// world returns the world that the game is currently in.
+// world returns the world that the game is currently in, or nil if the world of the game is not loaded.
 func (g *Game) world() *world.World {
 	if g.State().Waiting() {
-		return DefaultWaitingWorld
+		return g.waitingWorld()
 	}
-	if g.w != nil {
-		return g.w
+	return g.w
+}
+
+// waitingWorld returns the world that participants of the game wait in before it starts. If the game has no
+// waiting world of its own, or its waiting world is still being loaded, the DefaultWaitingWorld is returned.
+func (g *Game) waitingWorld() *world.World {
+	if w := g.waiting.Load(); w != nil {
+		return w
 	}
-	panic("world not loaded")
+	return DefaultWaitingWorld
+}
+
+// WaitingWorld returns the world that participants of the game wait in before it starts.
+func (g *Game) WaitingWorld() *world.World {
+	return g.waitingWorld()
 }
 
-// Join is used to join a player to the game. If the game is not in the waiting State, an error is returned.
+// Join is used to join a player to the game. If the game is not in the waiting State, an error is returned. The
+// player must be in the waiting world of the game or in the DefaultWaitingWorld. If the game has its own waiting
+// world, the player is moved to it, after it finished loading if it is still being loaded.
 func (g *Game) Join(p *player.Player) error {
+	_, err := g.join(p)
+	return err
+}
+
+// join joins the player passed to the game. It returns true if the player is being moved to the waiting world of
+// the game, in which case the join is completed in that world.
+func (g *Game) join(p *player.Player) (pending bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.handlePanic(p.Tx(), r)
+			err = ErrGameClosed
+		}
+	}()
+	if err := g.canJoin(p); err != nil {
+		return false, err
+	}
+
+	sess, _ := globalSessionManager.Load(p.XUID())
+	sess.SetGame(g)
+
+	par := &Participant{
+		name:  p.Name(),
+		xuid:  p.XUID(),
+		h:     p.H(),
+		state: ParticipantStatePlaying,
+	}
+
+	g.loadRating(par)
+	par.impl = g.impl.HandleParticipantCreate(par)
+	g.participants.Store(p.XUID(), par)
+
+	if g.waitingLoading() {
+		h := p.H()
+		go func() {
+			<-g.waitingReady
+			h.ExecWorld(func(tx *world.Tx, e world.Entity) {
+				if cur, ok := g.participants.Load(par.xuid); g.closed.Load() || !ok || cur != par {
+					return
+				}
+				g.moveToWaiting(e.(*player.Player), par)
+			})
+		}()
+		return true, nil
+	}
+	if p.Tx().World() != g.waitingWorld() {
+		g.moveToWaiting(p, par)
+		return true, nil
+	}
+	g.setupJoined(p, par)
+	return false, nil
+}
+
+// moveToWaiting moves the player passed, which joined as the participant passed, to the waiting world of the game,
+// where it is set up once it arrives.
+func (g *Game) moveToWaiting(p *player.Player, par *Participant) {
+	h := p.Tx().RemoveEntity(p)
+	g.waitingWorld().Exec(func(tx *world.Tx) {
+		newP := tx.AddEntity(h).(*player.Player)
+		if cur, ok := g.participants.Load(par.xuid); g.closed.Load() || !g.ValidTx(tx) || !ok || cur != par {
+			moveToLobby(newP)
+			return
+		}
+		defer g.recoverImpl(tx)
+		g.setupJoined(newP, par)
+	})
+}
+
+// cancelJoin undoes the join of the player passed. If the player is still being moved to the waiting world of the
+// game, it is removed from the game before it arrives and moved back to the lobby once it does.
+func (g *Game) cancelJoin(p *player.Player, pending bool) {
+	if !pending {
+		if _, err := g.Leave(p); err != nil {
+			g.log.Error("failed to remove player from game", "name", p.Name(), "error", err)
+		}
+		return
+	}
+	par, ok := g.participants.Load(p.XUID())
+	if !ok {
+		return
+	}
+	if sess, ok := globalSessionManager.Load(p.XUID()); ok {
+		sess.SetGame(nil)
+	}
+	if t, ok := par.Team(); ok {
+		t.remove(par)
+	}
+	g.participants.Delete(par.xuid)
+	par.close()
+}
+
+// canJoin returns an error if the player passed is not able to join the game.
+func (g *Game) canJoin(p *player.Player) error {
 	if g.closed.Load() {
-		return errors.New("game is closed")
+		return ErrGameClosed
 	}
 
 	if !g.State().Waiting() {
-		return errors.New("game is not in waiting State")
+		return ErrNotWaiting
 	}
 
-	if !g.ValidTx(p.Tx()) {
-		return errors.New("invalid tx: expected player to be in the waiting world")
+	if w := p.Tx().World(); w != g.waitingWorld() && w != DefaultWaitingWorld {
+		return fmt.Errorf("%w: expected player to be in the waiting world", ErrInvalidTx)
 	}
 
-	if g.participants.Len() >= g.impl.MaxPlayers() {
-		return errors.New("game is full")
+	if g.participants.Len() >= g.MaxPlayers() {
+		return ErrGameFull
 	}
 
 	sess, ok := globalSessionManager.Load(p.XUID())
 	if !ok {
-		return errors.New("player session not found")
+		return ErrSessionNotFound
 	}
 
 	if _, ok := sess.Game(); ok {
-		return errors.New("player is already in a game")
+		return ErrAlreadyInGame
 	}
 
-	sess.SetGame(g)
-	spawnPos := DefaultWaitingWorld.Spawn().Vec3Middle()
-	p.Teleport(spawnPos)
-	p.Messagef("Teleported to %.1f, %.1f, %.1f", spawnPos.X(), spawnPos.Y(), spawnPos.Z())
-
 	if allower, ok := g.impl.(Allower); ok {
-		reason, allowed := allower.Allow(p)
+		var reason string
+		allowed := false
+		g.callImpl(p.Tx(), func() {
+			reason, allowed = allower.Allow(p)
+		})
+		if g.closed.Load() {
+			return ErrGameClosed
+		}
 		if !allowed {
-			return fmt.Errorf("player not allowed to join: %s", reason)
+			return fmt.Errorf("%w: %s", ErrNotAllowed, reason)
 		}
 	}
+	return nil
+}
 
-	par := &Participant{
-		name:  p.Name(),
-		xuid:  p.XUID(),
-		h:     p.H(),
-		state: ParticipantStatePlaying,
-	}
-
-	par.impl = g.impl.HandleParticipantCreate(par)
-	g.participants.Store(p.XUID(), par)
+// setupJoined prepares a player that joined the game in the waiting world of the game.
+func (g *Game) setupJoined(p *player.Player, par *Participant) {
+	g.waitingSpawn(p)
+	spawnPos := p.Position()
+	p.Messagef("Teleported to %.1f, %.1f, %.1f", spawnPos.X(), spawnPos.Y(), spawnPos.Z())
 
 	resetPlayer(p)
 	p.SetGameMode(world.GameModeAdventure)
-	_ = p.Inventory().SetItem(0, voteMapItem)
-	_ = p.Inventory().SetItem(8, quitItem)
+	g.restoreKit(p, par)
+	g.giveHotbarItems(p, par, HotbarWaiting)
 
 	for e := range p.Tx().Players() {
 		if e.H() == p.H() {
@@ -302,8 +689,7 @@ func (g *Game) Join(p *player.Player) error {
 	}
 
 	g.impl.HandleJoin(p.Tx(), par)
-
-	return nil
+	g.events.publish(PlayerJoined{Game: g, Tx: p.Tx(), Participant: par})
 }
 
 // Participants returns the participants in the game.
@@ -360,56 +746,73 @@ func (g *Game) InGame(p *player.Player) bool {
 // Leave is used to remove a player from the game.
 func (g *Game) Leave(p *player.Player) (bool, error) {
 	if !g.ValidTx(p.Tx()) {
-		return false, errors.New("expected player to be in the world of the game")
+		return false, fmt.Errorf("%w: expected player to be in the world of the game", ErrInvalidTx)
 	}
 
 	sess, ok := globalSessionManager.Load(p.XUID())
 	if !ok {
-		return false, errors.New("player session not found")
+		return false, ErrSessionNotFound
 	}
 
 	currentG, ok := sess.Game()
-	if !ok {
-		return false, errors.New("player is not in a game")
-	}
-
-	if currentG != g {
-		return false, errors.New("player is not in this game")
+	if !ok || currentG != g {
+		return false, ErrNotInGame
 	}
 
 	sess.SetGame(nil)
 
 	par, ok := g.participants.Load(p.XUID())
 	if !ok {
-		return false, errors.New("player is not in the game")
+		return false, ErrNotInGame
 	}
 
-	g.impl.HandleQuit(p.Tx(), par)
+	tx := p.Tx()
+	g.callImpl(tx, func() {
+		g.impl.HandleQuit(tx, par)
+	})
+	g.events.publish(PlayerLeft{Game: g, Tx: tx, Participant: par})
+	if g.forfeitsOnLeave() {
+		g.recordGame(par, false, false)
+		g.recordLeaver(par)
+		if par.standing() {
+			g.eliminate(par)
+		}
+	}
 	resetPlayer(p)
 
+	if t, ok := par.Team(); ok {
+		t.remove(par)
+	}
 	g.participants.Delete(p.XUID())
 
 	worldChanged := false
-	if !g.State().Waiting() {
-		h := p.Tx().RemoveEntity(p)
-		<-DefaultWaitingWorld.Exec(func(newTx *world.Tx) {
-			newP := newTx.AddEntity(h).(*player.Player)
-			newP.Teleport(DefaultWaitingWorld.Spawn().Vec3Middle())
-			for e := range newTx.Players() {
-				if e.H() == newP.H() {
-					continue
-				}
-				e.(*player.Player).HideEntity(newP)
-				newP.HideEntity(e)
-			}
-		})
+	if p.Tx().World() != DefaultWaitingWorld {
+		moveToLobby(p)
 		worldChanged = true
 	}
 	par.close()
+	g.checkWin(tx)
 
 	return worldChanged, nil
 }
 
+// moveToLobby moves the player passed to the spawn of the DefaultWaitingWorld, hiding it from all other players
+// there. It returns once the player was added to the DefaultWaitingWorld.
+func moveToLobby(p *player.Player) {
+	h := p.Tx().RemoveEntity(p)
+	<-DefaultWaitingWorld.Exec(func(newTx *world.Tx) {
+		newP := newTx.AddEntity(h).(*player.Player)
+		newP.Teleport(DefaultWaitingWorld.Spawn().Vec3Middle())
+		for e := range newTx.Players() {
+			if e.H() == newP.H() {
+				continue
+			}
+			e.(*player.Player).HideEntity(newP)
+			newP.HideEntity(e)
+		}
+	})
+}
+
 // Players are used to iterate over all players in the game, calling the function passed for each player.
 func (g *Game) Players(tx *world.Tx, fn func(p *player.Player, par *Participant)) {
 	if !g.ValidTx(tx) {
@@ -454,51 +857,44 @@ func (g *Game) Messagef(tx *world.Tx, format string, args ...any) {
 	})
 }
 
-// loadMap ...
-func (g *Game) loadMap(tx *world.Tx) error {
-	if !g.ValidTx(tx) {
-		return errors.New("expected transaction to be valid")
-	}
-
-	if g.mapLoaded {
-		return errors.New("map already loaded")
-	}
-
-	votes := make([]int, len(g.availableMaps))
-
-	g.Players(tx, func(p *player.Player, par *Participant) {
-		if par.voteMapIndex == nil {
-			return
-		}
-
-		votes[*par.voteMapIndex]++
-	})
-
-	maxVotes := 0
-	maxVotesIndex := -1
-	for i, v := range votes {
-		if v > maxVotes {
-			maxVotes = v
-			maxVotesIndex = i
-		}
+// prepareMap selects the map that the game is played on and starts loading its world on a background goroutine,
+// so that the waiting world is not blocked while the world is loaded. The map is installed once its world is
+// ready. Calling prepareMap while the map is loading or loaded does nothing.
+func (g *Game) prepareMap(tx *world.Tx) {
+	if g.mapLoaded || g.mapLoading {
+		return
 	}
 
-	if maxVotes == 0 {
-		maxVotesIndex = rand.Intn(len(g.availableMaps))
+	selectedMap, err := g.selectMap(tx)
+	if err != nil {
+		g.log.Error("failed to select map", "error", err)
+		g.abort(tx, "no map is available")
+		return
 	}
-
-	selectedMap := g.availableMaps[maxVotesIndex]
+	g.selectedMap = selectedMap
 	g.log.Info("selected map", "map", selectedMap.Name)
+	g.announceMap(tx, selectedMap)
 
-	if err := selectedMap.CopyWorldTo(g.wPath); err != nil {
-		return fmt.Errorf("failed to copy map world: %w", err)
-	}
+	g.mapLoading = true
+	go func() {
+		w, err := g.newWorld(selectedMap)
+		g.waitingWorld().Exec(func(tx *world.Tx) {
+			g.mapPrepared(tx, selectedMap, w, err)
+		})
+	}()
+}
 
-	prov, err := mcdb.Open(g.wPath)
+// newWorld creates the world of a game played on the map passed.
+func (g *Game) newWorld(m *Map) (*world.World, error) {
+	prov, err := g.openWorld(m)
 	if err != nil {
-		return fmt.Errorf("failed to open world: %w", err)
+		return nil, err
 	}
+	return g.newInstanceWorld(prov), nil
+}
 
+// newInstanceWorld creates a world of the game that is read from the provider passed.
+func (g *Game) newInstanceWorld(prov world.Provider) *world.World {
 	wConf := world.Config{
 		Dim:          world.Overworld,
 		Provider:     prov,
@@ -508,18 +904,39 @@ func (g *Game) loadMap(tx *world.Tx) error {
 		Entities:     entity.DefaultRegistry,
 	}
 
-	g.w = wConf.New()
-	g.w.StopTime()
-	g.w.SetTime(3000)
-	g.w.StopThundering()
-	g.w.StopRaining()
-	g.w.StopWeatherCycle()
-	g.w.SetDifficulty(world.DifficultyEasy)
+	w := wConf.New()
+	w.StopTime()
+	w.SetTime(3000)
+	w.StopThundering()
+	w.StopRaining()
+	w.StopWeatherCycle()
+	w.SetDifficulty(world.DifficultyEasy)
 
-	g.w.Handle(&worldHandler{g: g})
+	w.Handle(&worldHandler{g: g})
+	return w
+}
+
+// mapPrepared is called on the transaction of the waiting world once the world of the map passed was loaded, or
+// failed to load. If loading failed, the game is cancelled. If the game was waiting for the map to start, it is
+// started.
+func (g *Game) mapPrepared(tx *world.Tx, m *Map, w *world.World, err error) {
+	defer g.recoverImpl(tx)
+	g.mapLoading = false
+	if g.closed.Load() || !g.State().Waiting() {
+		if w != nil {
+			g.discardWorld(w)
+		}
+		return
+	}
+	if err != nil {
+		g.log.Error("failed to load map", "map", m.Name, "error", err)
+		g.abort(tx, "the map could not be loaded")
+		return
+	}
 
+	g.w = w
 	g.mapLoaded = true
-	g.m = selectedMap
+	g.m = m
 
 	if v, ok := g.wh.(SetterGame); ok {
 		v.SetGame(g)
@@ -534,25 +951,116 @@ func (g *Game) loadMap(tx *world.Tx) error {
 	}
 
 	g.impl.HandleMapReady(tx, g.m)
+	g.events.publish(MapSelected{Game: g, Tx: tx, Map: g.m})
 
-	return nil
+	if !g.startPending {
+		return
+	}
+	if g.participants.Len() < g.MinPlayers() {
+		// Participants left while the map was loading, so the game waits for players again.
+		g.resetCountdown()
+		return
+	}
+	g.Start(tx)
+}
+
+// resetCountdown cancels a pending start of the game and resets its countdown to the waiting duration of the
+// Impl. The map stays selected, if it was.
+func (g *Game) resetCountdown() {
+	g.startPending = false
+	g.startingIn = int(g.impl.WaitingDuration().Seconds())
+}
+
+// discardWorld closes the world passed and removes its directory, if it has one.
+func (g *Game) discardWorld(w *world.World) {
+	_ = w.Close()
+	if err := os.RemoveAll(g.wPath); err != nil {
+		g.log.Error("failed to remove world directory", "path", g.wPath, "error", err)
+	}
 }
 
-// Start is used to start the game. If the game is not in the waiting State, this function does nothing.
+// announceMap announces the map passed to all participants of the game, along with its author and description.
+func (g *Game) announceMap(tx *world.Tx, m *Map) {
+	g.Messagef(tx, "<yellow>Map: <aqua>%s</aqua></yellow>", m.Title())
+	if m.Author != "" {
+		g.Messagef(tx, "<grey>Built by %s</grey>", m.Author)
+	}
+	if m.Description != "" {
+		g.Messagef(tx, "<grey>%s</grey>", m.Description)
+	}
+}
+
+// Maps returns the maps that the game may be played on that have all the tags passed.
+func (g *Game) Maps(tags ...string) []*Map {
+	maps := make([]*Map, 0, len(g.availableMaps))
+	for _, m := range g.availableMaps {
+		if m.HasTags(tags...) {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
+
+// selectMap selects the map that the game will be played on. If the game is pinned to a map, that map is
+// returned. Otherwise, the map is selected by the MapSelector of the game from the maps on the ballot that fit the
+// participants, or from all such maps if none of the maps on the ballot do. Maps that are vetoed or whose maximum
+// amount of players is below the amount of participants are never selected, and an error is returned if no map
+// is left.
+func (g *Game) selectMap(tx *world.Tx) (*Map, error) {
+	if g.pinnedMap != nil {
+		return g.pinnedMap, nil
+	}
+
+	players := g.participants.Len()
+	fit := func(maps []*Map) []*Map {
+		return slices.DeleteFunc(slices.Clone(g.withoutVetoed(tx, maps)), func(m *Map) bool {
+			return m.MaxPlayers > 0 && m.MaxPlayers < players
+		})
+	}
+	ballot := fit(g.ballot)
+	if len(ballot) == 0 {
+		ballot = fit(g.availableMaps)
+	}
+	if len(ballot) == 0 {
+		return nil, fmt.Errorf("no map that is not vetoed fits %d players", players)
+	}
+	counts := g.Votes()
+	votes := make([]int, len(ballot))
+	for i, m := range ballot {
+		votes[i] = counts[m]
+	}
+
+	return selectWith(g.mapSelector, MapSelection{
+		Maps:    ballot,
+		Votes:   votes,
+		Players: players,
+	}), nil
+}
+
+// Start is used to start the game. If the game is not in the waiting State, this function does nothing. If the
+// map of the game is not loaded yet, it is loaded first and the game starts once it is ready.
 func (g *Game) Start(tx *world.Tx) {
 	if !g.ValidTx(tx) || !g.State().Waiting() {
 		return
 	}
 	if !g.mapLoaded {
-		if err := g.loadMap(tx); err != nil {
-			panic(err)
-		}
+		g.startPending = true
+		g.prepareMap(tx)
+		return
 	}
+	g.startPending = false
+
+	g.assignTeams()
+
+	g.assignSpawns()
 
 	h := make([]*world.EntityHandle, 0, g.participants.Len())
 
-	g.Players(tx, func(p *player.Player, _ *Participant) {
+	g.Players(tx, func(p *player.Player, par *Participant) {
 		resetPlayer(p)
+		if g.respawn != nil {
+			par.lives = g.respawn.Lives
+		}
 
 		pH := tx.RemoveEntity(p)
 		h = append(h, pH)
@@ -561,22 +1069,56 @@ func (g *Game) Start(tx *world.Tx) {
 	g.setState(StatePlaying)
 
 	g.w.Exec(func(newTx *world.Tx) {
+		defer g.recoverImpl(newTx)
 		for _, pH := range h {
 			newTx.AddEntity(pH)
 		}
+		g.Players(newTx, func(p *player.Player, par *Participant) {
+			if spawn, ok := par.Spawn(); ok {
+				spawn.Teleport(p)
+			}
+			g.applyKit(p, par)
+		})
 
 		g.impl.HandleStart(newTx)
 	})
 }
 
-// End is used to end the game.
-func (g *Game) End(tx *world.Tx) {
+// assignSpawns assigns a spawn of the map to every playing participant. Participants in a team with a team
+// spawn are assigned that spawn, others are spread over the spawns of the map. Maps with fewer spawns than the
+// maximum amount of players are not played, so participants only share spawns if more joined than the map allows.
+func (g *Game) assignSpawns() {
+	i := 0
+	for par := range g.PlayingParticipants() {
+		if t, ok := par.Team(); ok {
+			if spawn, ok := g.m.TeamSpawns[t.name]; ok {
+				par.spawn = &spawn
+				continue
+			}
+		}
+		if len(g.m.Spawns) == 0 {
+			continue
+		}
+		if i == len(g.m.Spawns) {
+			g.log.Warn("more participants than spawns, spawns are shared", "map", g.m.Name, "spawns", len(g.m.Spawns))
+		}
+		spawn := g.m.Spawns[i%len(g.m.Spawns)]
+		par.spawn = &spawn
+		i++
+	}
+}
+
+// End is used to end the game with the result passed. The result is shown to all players and is available
+// through Result until the game is closed.
+func (g *Game) End(tx *world.Tx, res Result) {
 	if !g.ValidTx(tx) || !g.State().Playing() {
 		return
 	}
 
+	res = res.normalise()
+	g.result = &res
 	g.setState(StateFinished)
-	g.closingIn = 3
+	g.closingIn = int(g.lifecycle.FinishDuration.Seconds())
 
 	g.Players(tx, func(p *player.Player, par *Participant) {
 		resetPlayer(p)
@@ -586,26 +1128,52 @@ func (g *Game) End(tx *world.Tx) {
 		}
 
 		_ = p.SetHeldSlot(1)
-		_ = p.Inventory().SetItem(0, playAgainItem)
-		_ = p.Inventory().SetItem(8, quitItem)
+		g.giveHotbarItems(p, par, HotbarFinished)
+
+		g.announceResult(p, par, res)
 	})
+
+	for par := range g.Participants() {
+		g.recordGame(par, res.Won(par), res.Draw())
+	}
+	g.updateRatings(res)
+	g.events.publish(GameEnded{Game: g, Tx: tx, Result: res})
 }
 
 // close is used to close the game.
 func (g *Game) close(tx *world.Tx) {
-	if g.closed.Load() {
+	if g.closed.Load() || g.closing {
 		return
 	}
 	if !g.ValidTx(tx) {
 		g.log.Warn("expected transaction to be valid when closing game")
 		return
 	}
+	g.closing = true
 
-	g.impl.HandleClose(tx)
+	g.callImpl(tx, func() {
+		g.impl.HandleClose(tx)
+	})
 
 	g.Players(tx, func(p *player.Player, par *Participant) {
 		g.playAgain(p)
 	})
+	g.release(tx)
+}
+
+// release marks the game as closed, removes it from its Factory and closes its worlds. Players must have left the
+// game already.
+func (g *Game) release(tx *world.Tx) {
+	if g.closed.Load() {
+		return
+	}
+	g.closing = true
+	for par := range g.Participants() {
+		if par.state.Disconnected() && g.takePendingRejoin(par.xuid) {
+			g.participants.Delete(par.xuid)
+			par.close()
+		}
+	}
 
 	g.setState(StateUnknown)
 	g.closed.Store(true)
@@ -613,38 +1181,55 @@ func (g *Game) close(tx *world.Tx) {
 	if g.closeHook != nil {
 		(g.closeHook)()
 	}
+	g.events.publish(GameClosed{Game: g, Tx: tx})
 
 	if g.w != nil {
 		DefaultWaitingWorld.Exec(func(tx *world.Tx) {
-			_ = g.w.Close()
-			if err := os.RemoveAll(g.wPath); err != nil {
-				g.log.Error("failed to remove world directory", "path", g.wPath, "error", err)
-			}
+			g.discardWorld(g.w)
+		})
+	}
+	if w := g.waiting.Load(); g.ownWaiting && w != nil {
+		DefaultWaitingWorld.Exec(func(tx *world.Tx) {
+			_ = w.Close()
 		})
 	}
 
 	g.log.Info("game closed")
 }
 
-// SetSpectator is used to set a player to spectator mode.
+// SetSpectator is used to set a player to spectator mode. A playing participant is eliminated, after which the win
+// conditions of the game are evaluated.
 func (g *Game) SetSpectator(p *player.Player) {
+	if g.setSpectator(p) {
+		g.checkWin(p.Tx())
+	}
+}
+
+// setSpectator sets the player passed to spectator mode. It returns true if the player was a playing participant.
+func (g *Game) setSpectator(p *player.Player) bool {
 	if !g.ValidTx(p.Tx()) {
-		return
+		return false
 	}
 
 	par, ok := g.participants.Load(p.XUID())
 	if !ok {
-		return
+		return false
 	}
 
+	wasPlaying := par.state.Playing()
+	if wasPlaying {
+		g.eliminate(par)
+	}
 	par.state = ParticipantStateSpectating
 
 	resetPlayer(p)
 	p.SetGameMode(world.GameModeSpectator)
+	if g.mapLoaded && g.m.SpectatorSpawn != nil {
+		g.m.SpectatorSpawn.Teleport(p)
+	}
 	_ = p.SetHeldSlot(1)
-	_ = p.Inventory().SetItem(0, playAgainItem)
-	_ = p.Inventory().SetItem(4, teleporterItem)
-	_ = p.Inventory().SetItem(8, quitItem)
+	g.giveHotbarItems(p, par, HotbarSpectating)
+	return wasPlaying
 }
 
 func (g *Game) playAgain(p *player.Player) {
@@ -654,7 +1239,8 @@ func (g *Game) playAgain(p *player.Player) {
 
 	worldChanged, err := g.Leave(p)
 	if err != nil {
-		panic(err)
+		g.log.Error("failed to remove player from game", "name", p.Name(), "error", err)
+		return
 	}
 
 	if worldChanged {
@@ -0,0 +1,212 @@
+package game
+
+import (
+	"cmp"
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"slices"
+	"sync"
+)
+
+// HotbarState is a set of states of a participant in which a HotbarItem is in its hotbar.
+type HotbarState uint8
+
+const (
+	// HotbarWaiting is the state of participants while the game is waiting to start.
+	HotbarWaiting HotbarState = 1 << iota
+	// HotbarSpectating is the state of participants that became spectators while the game is playing.
+	HotbarSpectating
+	// HotbarFinished is the state of participants after the game ended.
+	HotbarFinished
+)
+
+// IDs of the hotbar items of the game itself. Registering an item with one of these IDs replaces the item, for
+// example to localise it.
+const (
+	HotbarVoteMap      = "voteMap"
+	HotbarTeamSelector = "teamSelector"
+	HotbarKitSelector  = "kitSelector"
+	HotbarQuit         = "quit"
+	HotbarPlayAgain    = "playAgain"
+	HotbarTeleporter   = "teleporter"
+)
+
+// HotbarItem is an item put in the hotbar of participants in some of the states of the game, which does
+// something when it is used.
+type HotbarItem struct {
+	// ID identifies the item. Registering an item with the ID of an existing item replaces it.
+	ID string
+	// Slot is the hotbar slot that the item is put in.
+	Slot int
+	// States are the states in which the item is put in the hotbar.
+	States HotbarState
+	// Stack returns the item stack put in the hotbar of the player passed, which may be localised for the player.
+	Stack func(p *player.Player) item.Stack
+	// Visible returns whether the item is put in the hotbar of the participant passed. If nil, the item is always
+	// put in the hotbar.
+	Visible func(g *Game, par *Participant) bool
+	// Click is called when the player passed uses the item. It may be nil for purely cosmetic items.
+	Click func(g *Game, p *player.Player)
+}
+
+// HotbarItemProvider may be implemented by an Impl to add its own items to the hotbar of participants.
+type HotbarItemProvider interface {
+	// HotbarItems returns the items that are registered in the HotbarRegistry of the game when it is loaded.
+	HotbarItems() []HotbarItem
+}
+
+// HotbarRegistry holds the hotbar items of a game.
+type HotbarRegistry struct {
+	mu    sync.RWMutex
+	items map[string]HotbarItem
+}
+
+// newHotbarRegistry creates a registry that holds the hotbar items of the game itself.
+func newHotbarRegistry() *HotbarRegistry {
+	r := &HotbarRegistry{items: make(map[string]HotbarItem)}
+	for _, i := range defaultHotbarItems() {
+		r.Register(i)
+	}
+	return r
+}
+
+// Register registers the item passed, replacing the item with the same ID if there is one.
+func (r *HotbarRegistry) Register(i HotbarItem) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items[i.ID] = i
+}
+
+// Unregister removes the item with the ID passed.
+func (r *HotbarRegistry) Unregister(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, id)
+}
+
+// Item returns the item with the ID passed, if it is registered.
+func (r *HotbarRegistry) Item(id string) (HotbarItem, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i, ok := r.items[id]
+	return i, ok
+}
+
+// Items returns all registered items, ordered by slot.
+func (r *HotbarRegistry) Items() []HotbarItem {
+	r.mu.RLock()
+	items := make([]HotbarItem, 0, len(r.items))
+	for _, i := range r.items {
+		items = append(items, i)
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(items, func(a, b HotbarItem) int {
+		return cmp.Or(cmp.Compare(a.Slot, b.Slot), cmp.Compare(a.ID, b.ID))
+	})
+	return items
+}
+
+// defaultHotbarItems returns the hotbar items of the game itself.
+func defaultHotbarItems() []HotbarItem {
+	return []HotbarItem{
+		{
+			ID:     HotbarVoteMap,
+			Slot:   0,
+			States: HotbarWaiting,
+			Stack:  staticStack(item.NewStack(item.Paper{}, 1).WithCustomName(text.Colourf("<yellow>Vote Map</yellow>"))),
+			Visible: func(g *Game, _ *Participant) bool {
+				return g.pinnedMap == nil
+			},
+			Click: sendVoteMapForm,
+		},
+		{
+			ID:     HotbarTeamSelector,
+			Slot:   1,
+			States: HotbarWaiting,
+			Stack:  staticStack(item.NewStack(block.Wool{Colour: item.ColourWhite()}, 1).WithCustomName(text.Colourf("<aqua>Select Team</aqua>"))),
+			Visible: func(g *Game, _ *Participant) bool {
+				return g.teamSelector && len(g.teams) > 0
+			},
+			Click: sendTeamSelectorForm,
+		},
+		{
+			ID:     HotbarKitSelector,
+			Slot:   2,
+			States: HotbarWaiting,
+			Stack:  staticStack(item.NewStack(block.NewChest(), 1).WithCustomName(text.Colourf("<gold>Select Kit</gold>"))),
+			Visible: func(g *Game, _ *Participant) bool {
+				return len(g.kits) > 0
+			},
+			Click: sendKitSelectorForm,
+		},
+		{
+			ID:     HotbarPlayAgain,
+			Slot:   0,
+			States: HotbarSpectating | HotbarFinished,
+			Stack:  staticStack(item.NewStack(item.Paper{}, 1).WithCustomName(text.Colourf("<green>Play Again</green>"))),
+			Click: func(g *Game, p *player.Player) {
+				g.playAgain(p)
+			},
+		},
+		{
+			ID:     HotbarTeleporter,
+			Slot:   4,
+			States: HotbarSpectating,
+			Stack:  staticStack(item.NewStack(item.Compass{}, 1).WithCustomName(text.Colourf("<yellow>Teleporter</yellow>"))),
+			Click:  sendTeleporterForm,
+		},
+		{
+			ID:     HotbarQuit,
+			Slot:   8,
+			States: HotbarWaiting | HotbarSpectating | HotbarFinished,
+			Stack:  staticStack(item.NewStack(item.DragonBreath{}, 1).WithCustomName(text.Colourf("<red>Quit</red>"))),
+			Click: func(g *Game, p *player.Player) {
+				_, _ = g.Leave(p)
+			},
+		},
+	}
+}
+
+// staticStack returns a Stack function of a HotbarItem that returns the same stack for every player.
+func staticStack(s item.Stack) func(p *player.Player) item.Stack {
+	return func(*player.Player) item.Stack {
+		return s
+	}
+}
+
+// gameItemKey is the key of the value of item stacks that holds the ID of the hotbar item that they belong to.
+const gameItemKey = "gameItem"
+
+// Hotbar returns the HotbarRegistry of the game. Items registered while players are in a state of the item are
+// only put in their hotbar when they enter a state again.
+func (g *Game) Hotbar() *HotbarRegistry {
+	return g.hotbar
+}
+
+// giveHotbarItems puts the hotbar items of the state passed in the hotbar of the player passed.
+func (g *Game) giveHotbarItems(p *player.Player, par *Participant, state HotbarState) {
+	for _, i := range g.hotbar.Items() {
+		if i.States&state == 0 || (i.Visible != nil && !i.Visible(g, par)) {
+			continue
+		}
+		_ = p.Inventory().SetItem(i.Slot, i.Stack(p).WithValue(gameItemKey, i.ID))
+	}
+}
+
+// useHotbarItem handles the use of the item stack passed by the player passed. It returns false if the stack is
+// not a hotbar item of the game.
+func (g *Game) useHotbarItem(p *player.Player, s item.Stack) bool {
+	val, ok := s.Value(gameItemKey)
+	if !ok {
+		return false
+	}
+	id, _ := val.(string)
+	if i, ok := g.hotbar.Item(id); ok && i.Click != nil {
+		g.callImpl(p.Tx(), func() {
+			i.Click(g, p)
+		})
+	}
+	return true
+}
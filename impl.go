@@ -26,8 +26,9 @@ type Impl interface {
 	HandlePlayingTick(tx *world.Tx, currentTick uint64)
 	// RenderWaitingScoreboard renders the scoreboard for the waiting State.
 	RenderWaitingScoreboard(p *player.Player, startingIn int, participantLen int)
-	// RenderFinishedScoreboard renders the scoreboard for the finished State.
-	RenderFinishedScoreboard(p *player.Player, closingIn int)
+	// RenderFinishedScoreboard renders the scoreboard for the finished State, using the result that the game ended
+	// with.
+	RenderFinishedScoreboard(p *player.Player, closingIn int, res Result)
 	// HandleMapReady is called when the map is ready to be played.
 	HandleMapReady(tx *world.Tx, m *Map)
 	// Load is called when the game is loaded.
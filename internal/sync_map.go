@@ -41,6 +41,17 @@ func (sm *Map[K, V]) Delete(key K) {
 	delete(sm.m, key)
 }
 
+func (sm *Map[K, V]) DeleteFunc(key K, f func(value V) bool) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	value, ok := sm.m[key]
+	if !ok || !f(value) {
+		return false
+	}
+	delete(sm.m, key)
+	return true
+}
+
 func (sm *Map[K, V]) Len() int {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
@@ -0,0 +1,365 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"github.com/df-mc/dragonfly/server/entity/effect"
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+	"gopkg.in/yaml.v3"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrKitLocked is returned when a participant selects a kit that it has not unlocked.
+var ErrKitLocked = errors.New("kit is locked")
+
+// kitsFile is the name of the file in the maps directory that kits are defined in.
+const kitsFile = "kits.yml"
+
+// kitEffectDuration is the duration of effects of kits that have no duration, which last for the rest of the game.
+const kitEffectDuration = time.Duration(math.MaxInt32) * time.Second / 20
+
+// Kit is a loadout that participants select while the game is waiting. The kit is given to participants when the
+// game starts and every time they respawn.
+type Kit struct {
+	// Name identifies the kit. It is remembered as the last kit selected by players.
+	Name string
+	// DisplayName is the name of the kit shown to players. If empty, Name is shown.
+	DisplayName string
+	// Description is a short description of the kit shown in the kit selector. It may be empty.
+	Description string
+	// Icon is the path to a texture in a resource pack or the URL of an image that is shown in the kit selector.
+	// It may be empty.
+	Icon string
+	// Items are the items put in the inventory, keyed by slot.
+	Items map[int]item.Stack
+	// Helmet, Chestplate, Leggings and Boots are the armour pieces put on. Empty stacks leave the slot empty.
+	Helmet, Chestplate, Leggings, Boots item.Stack
+	// Effects are the effects added.
+	Effects []effect.Effect
+	// Permission is the permission that players need to select the kit, checked using Config.KitPermission. If
+	// empty, all players are able to select the kit.
+	Permission string
+	// Unlocked returns whether the player passed is able to select the kit. If non-nil, it is used instead of
+	// Permission.
+	Unlocked func(p *player.Player) bool
+}
+
+// Title returns the name of the kit that is shown to players: its DisplayName, or Name if it has none.
+func (k *Kit) Title() string {
+	if k.DisplayName != "" {
+		return k.DisplayName
+	}
+	return k.Name
+}
+
+// Apply gives the items, armour and effects of the kit to the player passed. Items in slots of the kit are
+// replaced, other items are kept.
+func (k *Kit) Apply(p *player.Player) {
+	for slot, s := range k.Items {
+		_ = p.Inventory().SetItem(slot, s)
+	}
+	p.Armour().Set(k.Helmet, k.Chestplate, k.Leggings, k.Boots)
+	for _, e := range k.Effects {
+		p.AddEffect(e)
+	}
+}
+
+// kitsConfig is the representation of the kits.yml file in the maps directory.
+type kitsConfig struct {
+	Kits []kitConfig `yaml:"kits"`
+}
+
+// kitConfig is the representation of a Kit in kits.yml.
+type kitConfig struct {
+	Name        string            `yaml:"name"`
+	DisplayName string            `yaml:"display_name"`
+	Description string            `yaml:"description"`
+	Icon        string            `yaml:"icon"`
+	Permission  string            `yaml:"permission"`
+	Items       []kitItemConfig   `yaml:"items"`
+	Armour      kitArmourConfig   `yaml:"armour"`
+	Effects     []kitEffectConfig `yaml:"effects"`
+}
+
+// kitItemConfig is the representation of an item stack in kits.yml.
+type kitItemConfig struct {
+	Slot         int            `yaml:"slot"`
+	Item         string         `yaml:"item"`
+	Meta         int16          `yaml:"meta"`
+	Count        int            `yaml:"count"`
+	Name         string         `yaml:"name"`
+	Lore         []string       `yaml:"lore"`
+	Enchantments map[string]int `yaml:"enchantments"`
+}
+
+// kitArmourConfig is the representation of the armour of a Kit in kits.yml.
+type kitArmourConfig struct {
+	Helmet     *kitItemConfig `yaml:"helmet"`
+	Chestplate *kitItemConfig `yaml:"chestplate"`
+	Leggings   *kitItemConfig `yaml:"leggings"`
+	Boots      *kitItemConfig `yaml:"boots"`
+}
+
+// kitEffectConfig is the representation of an effect in kits.yml. A duration of 0 means that the effect lasts for
+// the rest of the game.
+type kitEffectConfig struct {
+	Type     string  `yaml:"type"`
+	Level    int     `yaml:"level"`
+	Duration float64 `yaml:"duration"`
+}
+
+// effectTypes are the effects that may be used in kits.yml, keyed by name.
+var effectTypes = map[string]effect.LastingType{
+	"speed":           effect.Speed,
+	"slowness":        effect.Slowness,
+	"haste":           effect.Haste,
+	"mining_fatigue":  effect.MiningFatigue,
+	"strength":        effect.Strength,
+	"jump_boost":      effect.JumpBoost,
+	"nausea":          effect.Nausea,
+	"regeneration":    effect.Regeneration,
+	"resistance":      effect.Resistance,
+	"fire_resistance": effect.FireResistance,
+	"water_breathing": effect.WaterBreathing,
+	"invisibility":    effect.Invisibility,
+	"blindness":       effect.Blindness,
+	"night_vision":    effect.NightVision,
+	"hunger":          effect.Hunger,
+	"weakness":        effect.Weakness,
+	"poison":          effect.Poison,
+	"wither":          effect.Wither,
+	"health_boost":    effect.HealthBoost,
+	"absorption":      effect.Absorption,
+	"saturation":      effect.Saturation,
+	"levitation":      effect.Levitation,
+	"fatal_poison":    effect.FatalPoison,
+	"conduit_power":   effect.ConduitPower,
+	"slow_falling":    effect.SlowFalling,
+	"darkness":        effect.Darkness,
+}
+
+// stack converts the item config to an item stack.
+func (c kitItemConfig) stack() (item.Stack, error) {
+	it, ok := world.ItemByName(c.Item, c.Meta)
+	if !ok {
+		return item.Stack{}, fmt.Errorf("unknown item %s", c.Item)
+	}
+	count := c.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 {
+		return item.Stack{}, fmt.Errorf("item %s: count must not be negative", c.Item)
+	}
+	s := item.NewStack(it, count)
+	if c.Name != "" {
+		s = s.WithCustomName(c.Name)
+	}
+	if len(c.Lore) > 0 {
+		s = s.WithLore(c.Lore...)
+	}
+	for name, lvl := range c.Enchantments {
+		t, ok := enchantmentByName(name)
+		if !ok {
+			return item.Stack{}, fmt.Errorf("item %s: unknown enchantment %s", c.Item, name)
+		}
+		s = s.WithEnchantments(item.NewEnchantment(t, lvl))
+	}
+	return s, nil
+}
+
+// enchantmentByName returns the enchantment with the name passed, such as "sharpness" or "fire_aspect".
+func enchantmentByName(name string) (item.EnchantmentType, bool) {
+	name = strings.ReplaceAll(name, "_", " ")
+	for _, t := range item.Enchantments() {
+		if strings.EqualFold(t.Name(), name) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// kit converts the kit config to a Kit.
+func (c kitConfig) kit() (*Kit, error) {
+	if c.Name == "" {
+		return nil, errors.New("kit has no name")
+	}
+	k := &Kit{
+		Name:        c.Name,
+		DisplayName: c.DisplayName,
+		Description: c.Description,
+		Icon:        c.Icon,
+		Permission:  c.Permission,
+		Items:       make(map[int]item.Stack, len(c.Items)),
+	}
+	for _, i := range c.Items {
+		if i.Slot < 0 || i.Slot >= 36 {
+			return nil, fmt.Errorf("kit %s: slot %d is out of range", c.Name, i.Slot)
+		}
+		s, err := i.stack()
+		if err != nil {
+			return nil, fmt.Errorf("kit %s: %w", c.Name, err)
+		}
+		k.Items[i.Slot] = s
+	}
+	for _, a := range []struct {
+		conf *kitItemConfig
+		s    *item.Stack
+	}{
+		{c.Armour.Helmet, &k.Helmet},
+		{c.Armour.Chestplate, &k.Chestplate},
+		{c.Armour.Leggings, &k.Leggings},
+		{c.Armour.Boots, &k.Boots},
+	} {
+		if a.conf == nil {
+			continue
+		}
+		s, err := a.conf.stack()
+		if err != nil {
+			return nil, fmt.Errorf("kit %s: %w", c.Name, err)
+		}
+		*a.s = s
+	}
+	for _, e := range c.Effects {
+		t, ok := effectTypes[e.Type]
+		if !ok {
+			return nil, fmt.Errorf("kit %s: unknown effect %s", c.Name, e.Type)
+		}
+		if e.Duration < 0 {
+			return nil, fmt.Errorf("kit %s: effect %s: duration must not be negative", c.Name, e.Type)
+		}
+		d := kitEffectDuration
+		if e.Duration > 0 {
+			d = time.Duration(e.Duration * float64(time.Second))
+		}
+		k.Effects = append(k.Effects, effect.New(t, max(e.Level, 1), d))
+	}
+	return k, nil
+}
+
+// loadKits loads the kits defined in the kits.yml file in the directory passed. If the file does not exist, no
+// kits are returned.
+func loadKits(dir string) ([]*Kit, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, kitsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var conf kitsConfig
+	if err := yaml.Unmarshal(raw, &conf); err != nil {
+		return nil, err
+	}
+	kits := make([]*Kit, 0, len(conf.Kits))
+	names := make(map[string]struct{}, len(conf.Kits))
+	for _, c := range conf.Kits {
+		k, err := c.kit()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := names[k.Name]; ok {
+			return nil, fmt.Errorf("duplicate kit %s", k.Name)
+		}
+		names[k.Name] = struct{}{}
+		kits = append(kits, k)
+	}
+	return kits, nil
+}
+
+// Kits returns the kits that participants are able to select, in the order they are shown in the kit selector.
+func (g *Game) Kits() []*Kit {
+	return g.kits
+}
+
+// Kit returns the kit with the name passed, if the game has it.
+func (g *Game) Kit(name string) (*Kit, bool) {
+	for _, k := range g.kits {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// KitUnlocked returns whether the player passed is able to select the kit passed.
+func (g *Game) KitUnlocked(p *player.Player, k *Kit) bool {
+	if k.Unlocked != nil {
+		return k.Unlocked(p)
+	}
+	if k.Permission == "" {
+		return true
+	}
+	return g.kitPermission != nil && g.kitPermission(p, k.Permission)
+}
+
+// SelectKit selects the kit passed for the player passed, which receives it when the game starts and when it
+// respawns. The selection is remembered in the KitStore of the game, so that the kit is selected again in the
+// next game of the mode.
+func (g *Game) SelectKit(p *player.Player, k *Kit) error {
+	if !g.State().Waiting() {
+		return ErrNotWaiting
+	}
+	par, ok := g.participants.Load(p.XUID())
+	if !ok {
+		return ErrNotInGame
+	}
+	if !g.KitUnlocked(p, k) {
+		return ErrKitLocked
+	}
+	par.kit = k
+	if err := g.kitStore.SetKit(g.mode, par.xuid, k.Name); err != nil {
+		g.log.Error("failed to save selected kit", "name", par.name, "error", err)
+	}
+	return nil
+}
+
+// restoreKit selects the kit that the player passed selected last in the mode of the game, if it is still
+// unlocked.
+func (g *Game) restoreKit(p *player.Player, par *Participant) {
+	if len(g.kits) == 0 {
+		return
+	}
+	name, err := g.kitStore.Kit(g.mode, par.xuid)
+	if err != nil {
+		g.log.Error("failed to load selected kit", "name", par.name, "error", err)
+		return
+	}
+	if k, ok := g.Kit(name); ok && g.KitUnlocked(p, k) {
+		par.kit = k
+	}
+}
+
+// selectedKit returns the kit that the participant passed receives: its selected kit, or the default kit of the
+// game if it did not select one.
+func (g *Game) selectedKit(p *player.Player, par *Participant) (*Kit, bool) {
+	if par.kit != nil && g.KitUnlocked(p, par.kit) {
+		return par.kit, true
+	}
+	if k, ok := g.Kit(g.defaultKit); ok && g.KitUnlocked(p, k) {
+		return k, true
+	}
+	return nil, false
+}
+
+// applyKit gives the kit of the participant passed to the player passed.
+func (g *Game) applyKit(p *player.Player, par *Participant) {
+	if k, ok := g.selectedKit(p, par); ok {
+		k.Apply(p)
+	}
+}
+
+// mergeKits returns the kits passed with kits in overrides replacing kits with the same name.
+func mergeKits(kits, overrides []*Kit) []*Kit {
+	merged := slices.DeleteFunc(slices.Clone(kits), func(k *Kit) bool {
+		return slices.ContainsFunc(overrides, func(o *Kit) bool { return o.Name == k.Name })
+	})
+	return append(merged, overrides...)
+}
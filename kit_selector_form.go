@@ -0,0 +1,43 @@
+package game
+
+import (
+	"fmt"
+	form "github.com/akmalfairuz/ez-form"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+func sendKitSelectorForm(g *Game, p *player.Player) {
+	par, ok := g.ParticipantByXUID(p.XUID())
+	if !ok {
+		return
+	}
+
+	kits := g.Kits()
+	selected, _ := g.selectedKit(p, par)
+	f := form.NewMenu("Select Kit")
+	f.WithContent("Select a kit to play with:")
+	for _, k := range kits {
+		switch {
+		case k == selected:
+			f.WithButton(text.Colourf("<dark-green>%s</dark-green>\n<green>selected</green>", k.Title()), k.Icon)
+		case !g.KitUnlocked(p, k):
+			f.WithButton(text.Colourf("<dark-grey>%s</dark-grey>\n<red>locked</red>", k.Title()), k.Icon)
+		default:
+			f.WithButton(fmt.Sprintf("%s\n%s", k.Title(), k.Description), k.Icon)
+		}
+	}
+	f.WithCallback(func(p *player.Player, result int) {
+		if g.closed.Load() || !g.InGame(p) {
+			return
+		}
+
+		k := kits[result]
+		if err := g.SelectKit(p, k); err != nil {
+			p.Message(text.Colourf("<red>%s</red>", err))
+			return
+		}
+		p.Message(text.Colourf("<yellow>You selected the %s kit</yellow>", k.Title()))
+	})
+	p.SendForm(f)
+}
@@ -0,0 +1,157 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// KitStore stores the kit that players selected last per game mode, keyed by XUID, so that the kit is selected
+// again in the next game of the mode.
+type KitStore interface {
+	// Kit returns the name of the kit that the player with the XUID passed selected last in the game mode passed,
+	// or an empty string if the player never selected a kit.
+	Kit(mode, xuid string) (string, error)
+	// SetKit stores the name of the kit that the player with the XUID passed selected in the game mode passed.
+	SetKit(mode, xuid, kit string) error
+	// Close closes the store, persisting any pending changes.
+	Close() error
+}
+
+// DefaultKitStore is the KitStore of games that have no KitStore configured. It keeps selections in memory, so
+// they are shared by all games but lost when the server restarts. Set it, or the KitStore of a Config, to a
+// JSONKitStore to remember selections across restarts.
+var DefaultKitStore KitStore = NewMemoryKitStore()
+
+// MemoryKitStore is a KitStore that keeps selected kits in memory.
+type MemoryKitStore struct {
+	mu   sync.Mutex
+	kits map[string]map[string]string
+}
+
+// NewMemoryKitStore creates an empty MemoryKitStore.
+func NewMemoryKitStore() *MemoryKitStore {
+	return &MemoryKitStore{kits: make(map[string]map[string]string)}
+}
+
+// Kit ...
+func (m *MemoryKitStore) Kit(mode, xuid string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.kits[mode][xuid], nil
+}
+
+// SetKit ...
+func (m *MemoryKitStore) SetKit(mode, xuid, kit string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	modeKits, ok := m.kits[mode]
+	if !ok {
+		modeKits = make(map[string]string)
+		m.kits[mode] = modeKits
+	}
+	modeKits[xuid] = kit
+	return nil
+}
+
+// Close ...
+func (m *MemoryKitStore) Close() error {
+	return nil
+}
+
+// JSONKitStore is a KitStore that keeps selected kits in memory and periodically writes them to a JSON file, so
+// that selections are remembered across restarts.
+type JSONKitStore struct {
+	*MemoryKitStore
+
+	path  string
+	dirty chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// NewJSONKitStore opens the JSON kit file at the path passed, creating it if it does not exist. Changes are
+// written to the file every flushInterval and when the store is closed. If flushInterval is not positive, changes
+// are written every 30 seconds.
+func NewJSONKitStore(path string, flushInterval time.Duration) (*JSONKitStore, error) {
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	s := &JSONKitStore{
+		MemoryKitStore: NewMemoryKitStore(),
+		path:           path,
+		dirty:          make(chan struct{}, 1),
+		done:           make(chan struct{}),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read kit file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.kits); err != nil {
+			return nil, fmt.Errorf("failed to decode kit file: %w", err)
+		}
+	}
+
+	s.wg.Add(1)
+	go s.flushLoop(flushInterval)
+	return s, nil
+}
+
+// SetKit ...
+func (s *JSONKitStore) SetKit(mode, xuid, kit string) error {
+	if err := s.MemoryKitStore.SetKit(mode, xuid, kit); err != nil {
+		return err
+	}
+	select {
+	case s.dirty <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close stops the periodic flushing of the store and writes all selected kits to the file.
+func (s *JSONKitStore) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+	return s.flush()
+}
+
+// flushLoop writes the selected kits to the file every interval if they changed. It returns when the store is
+// closed.
+func (s *JSONKitStore) flushLoop(interval time.Duration) {
+	defer s.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			select {
+			case <-s.dirty:
+				_ = s.flush()
+			default:
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// flush writes all selected kits to the file, replacing it atomically.
+func (s *JSONKitStore) flush() error {
+	s.mu.Lock()
+	data, err := json.Marshal(s.kits)
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode kits: %w", err)
+	}
+	return writeFileAtomic(s.path, data)
+}
@@ -0,0 +1,38 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJSONKitStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kits.json")
+	s, err := NewJSONKitStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to open kit store: %v", err)
+	}
+	if err := s.SetKit("bedwars", "1", "archer"); err != nil {
+		t.Fatalf("failed to set kit: %v", err)
+	}
+	if err := s.SetKit("skywars", "1", "knight"); err != nil {
+		t.Fatalf("failed to set kit: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close kit store: %v", err)
+	}
+
+	s, err = NewJSONKitStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to reopen kit store: %v", err)
+	}
+	defer s.Close()
+	for mode, want := range map[string]string{"bedwars": "archer", "skywars": "knight"} {
+		if got, err := s.Kit(mode, "1"); err != nil || got != want {
+			t.Fatalf("Kit(%q) after reload = %q, %v, want %q", mode, got, err, want)
+		}
+	}
+	if got, err := s.Kit("bedwars", "2"); err != nil || got != "" {
+		t.Fatalf("Kit of unknown player = %q, %v, want empty", got, err)
+	}
+}
@@ -0,0 +1,454 @@
+package game
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LeaderboardMetric is the value that players are ranked by on a leaderboard.
+type LeaderboardMetric uint8
+
+const (
+	LeaderboardWins LeaderboardMetric = iota
+	LeaderboardKills
+	LeaderboardWinRate
+)
+
+// String returns a human-readable name of the metric.
+func (m LeaderboardMetric) String() string {
+	switch m {
+	case LeaderboardKills:
+		return "Kills"
+	case LeaderboardWinRate:
+		return "Win Rate"
+	}
+	return "Wins"
+}
+
+// LeaderboardWindow is the period of time that a leaderboard covers.
+type LeaderboardWindow uint8
+
+const (
+	LeaderboardAllTime LeaderboardWindow = iota
+	LeaderboardWeekly
+	LeaderboardDaily
+)
+
+// String returns a human-readable name of the window.
+func (w LeaderboardWindow) String() string {
+	switch w {
+	case LeaderboardWeekly:
+		return "Weekly"
+	case LeaderboardDaily:
+		return "Daily"
+	}
+	return "All Time"
+}
+
+// since returns the earliest time covered by the window, or the zero time for LeaderboardAllTime.
+func (w LeaderboardWindow) since(now time.Time) time.Time {
+	switch w {
+	case LeaderboardWeekly:
+		return now.Add(-time.Hour * 24 * 7)
+	case LeaderboardDaily:
+		return now.Add(-time.Hour * 24)
+	}
+	return time.Time{}
+}
+
+// LeaderboardConfig is a configuration of a leaderboard shown in the waiting world of a Factory.
+type LeaderboardConfig struct {
+	// Title is shown above the entries of the leaderboard. If empty, a title is made of the metric and window.
+	Title string
+	// Metric is the value that players are ranked by.
+	Metric LeaderboardMetric
+	// Window is the period of time that the leaderboard covers.
+	Window LeaderboardWindow
+	// Size is the amount of entries shown. Defaults to 10.
+	Size int
+	// MinGames is the minimum amount of games that a player must have played to be ranked by win rate.
+	// Defaults to 1.
+	MinGames int
+	// Position is the position of the floating text in the waiting world.
+	Position mgl64.Vec3
+	// RefreshInterval is how often the leaderboard is updated. Defaults to 30 seconds.
+	RefreshInterval time.Duration
+}
+
+// LeaderboardEntry is a ranked player on a leaderboard.
+type LeaderboardEntry struct {
+	XUID  string
+	Name  string
+	Value float64
+}
+
+// leaderboardRecord is a single win, kill or played game of a player.
+type leaderboardRecord struct {
+	xuid string
+	at   time.Time
+	win  bool
+	kill bool
+}
+
+// leaderboardTotals are the all-time counts of a player.
+type leaderboardTotals struct {
+	wins, kills, games int
+}
+
+// leaderboardRecords holds the records of all games of a Factory that leaderboards are computed from. Records
+// older than a week are dropped, as only all-time totals are needed for them. If the records have a StatsStore,
+// all-time totals are read from the store instead, so that they persist across restarts.
+type leaderboardRecords struct {
+	store StatsStore
+	mode  string
+	log   *slog.Logger
+
+	mu      sync.Mutex
+	records []leaderboardRecord
+	totals  map[string]*leaderboardTotals
+	names   map[string]string
+
+	unsubscribe []func()
+}
+
+// newLeaderboardRecords creates leaderboard records that are filled from the events published on the bus passed.
+// All-time totals are read from the stats of the mode passed in the store passed, unless the store is nil.
+func newLeaderboardRecords(bus *EventBus, store StatsStore, mode string, log *slog.Logger) *leaderboardRecords {
+	r := &leaderboardRecords{
+		store:  store,
+		mode:   mode,
+		log:    log,
+		totals: make(map[string]*leaderboardTotals),
+		names:  make(map[string]string),
+	}
+	r.unsubscribe = []func(){
+		Subscribe(bus, func(e GameEnded) {
+			for par := range e.Game.Participants() {
+				r.add(par, leaderboardRecord{win: e.Result.Won(par)})
+			}
+		}),
+		Subscribe(bus, func(e PlayerLeft) {
+			if e.Game.forfeitsOnLeave() {
+				r.add(e.Participant, leaderboardRecord{})
+			}
+		}),
+		Subscribe(bus, func(e ParticipantDied) {
+			if e.Killer != nil && e.Killer != e.Victim {
+				r.add(e.Killer, leaderboardRecord{kill: true})
+			}
+		}),
+	}
+	return r
+}
+
+// close stops filling the records from events.
+func (r *leaderboardRecords) close() {
+	for _, unsubscribe := range r.unsubscribe {
+		unsubscribe()
+	}
+}
+
+// storedTotals returns the all-time totals and names of all players in the StatsStore of the records.
+func (r *leaderboardRecords) storedTotals() (map[string]leaderboardTotals, map[string]string, error) {
+	all, err := r.store.All(r.mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	totals := make(map[string]leaderboardTotals, len(all))
+	names := make(map[string]string, len(all))
+	for xuid, s := range all {
+		totals[xuid] = leaderboardTotals{wins: s.Wins, kills: s.Kills, games: s.GamesPlayed}
+		names[xuid] = s.Name
+	}
+	return totals, names, nil
+}
+
+// add adds a record for the participant passed.
+func (r *leaderboardRecords) add(par *Participant, rec leaderboardRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec.xuid, rec.at = par.xuid, time.Now()
+	r.records = append(r.records, rec)
+	r.names[par.xuid] = par.name
+
+	t, ok := r.totals[par.xuid]
+	if !ok {
+		t = &leaderboardTotals{}
+		r.totals[par.xuid] = t
+	}
+	switch {
+	case rec.kill:
+		t.kills++
+	case rec.win:
+		t.wins++
+		t.games++
+	default:
+		t.games++
+	}
+}
+
+// rank computes the entries of a leaderboard with the config passed.
+func (r *leaderboardRecords) rank(conf LeaderboardConfig) []LeaderboardEntry {
+	var stored map[string]leaderboardTotals
+	var storedNames map[string]string
+	if conf.Window == LeaderboardAllTime && r.store != nil {
+		var err error
+		if stored, storedNames, err = r.storedTotals(); err != nil {
+			r.log.Error("failed to read stats for leaderboard", "leaderboard", conf.Title, "error", err)
+		}
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	weekAgo := LeaderboardWeekly.since(now)
+	r.records = slices.DeleteFunc(r.records, func(rec leaderboardRecord) bool {
+		return rec.at.Before(weekAgo)
+	})
+
+	totals := make(map[string]leaderboardTotals)
+	if stored != nil {
+		totals = stored
+	} else if conf.Window == LeaderboardAllTime {
+		for xuid, t := range r.totals {
+			totals[xuid] = *t
+		}
+	} else {
+		since := conf.Window.since(now)
+		for _, rec := range r.records {
+			if rec.at.Before(since) {
+				continue
+			}
+			t := totals[rec.xuid]
+			switch {
+			case rec.kill:
+				t.kills++
+			case rec.win:
+				t.wins++
+				t.games++
+			default:
+				t.games++
+			}
+			totals[rec.xuid] = t
+		}
+	}
+	names := make(map[string]string, len(totals))
+	for xuid := range totals {
+		names[xuid] = r.names[xuid]
+		if names[xuid] == "" {
+			names[xuid] = cmp.Or(storedNames[xuid], xuid)
+		}
+	}
+	r.mu.Unlock()
+
+	entries := make([]LeaderboardEntry, 0, len(totals))
+	for xuid, t := range totals {
+		var v float64
+		switch conf.Metric {
+		case LeaderboardWins:
+			v = float64(t.wins)
+		case LeaderboardKills:
+			v = float64(t.kills)
+		case LeaderboardWinRate:
+			if t.games < conf.MinGames {
+				continue
+			}
+			v = float64(t.wins) / float64(t.games) * 100
+		}
+		if v <= 0 {
+			continue
+		}
+		entries = append(entries, LeaderboardEntry{XUID: xuid, Name: names[xuid], Value: v})
+	}
+	slices.SortFunc(entries, func(a, b LeaderboardEntry) int {
+		if c := cmp.Compare(b.Value, a.Value); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	if len(entries) > conf.Size {
+		entries = entries[:conf.Size]
+	}
+	return entries
+}
+
+// Leaderboard is a ranking of players shown as floating text in the waiting world of a Factory. Rankings are
+// computed in the background and only the floating text is updated on the world.
+type Leaderboard struct {
+	conf    LeaderboardConfig
+	records *leaderboardRecords
+	w       *world.World
+
+	mu      sync.Mutex
+	entries []LeaderboardEntry
+	h       *world.EntityHandle
+
+	done chan struct{}
+	once sync.Once
+}
+
+// newLeaderboard creates a leaderboard with the config passed and starts refreshing it. The leaderboard is shown
+// in the world passed, or the DefaultWaitingWorld if it is nil.
+func newLeaderboard(conf LeaderboardConfig, records *leaderboardRecords, w *world.World) *Leaderboard {
+	if conf.Size <= 0 {
+		conf.Size = 10
+	}
+	if conf.MinGames <= 0 {
+		conf.MinGames = 1
+	}
+	if conf.RefreshInterval <= 0 {
+		conf.RefreshInterval = time.Second * 30
+	}
+	if conf.Title == "" {
+		conf.Title = fmt.Sprintf("%s %s", conf.Window, conf.Metric)
+	}
+	lb := &Leaderboard{conf: conf, records: records, w: w, done: make(chan struct{})}
+	go lb.refreshLoop()
+	return lb
+}
+
+// Entries returns the entries of the leaderboard as of the last refresh.
+func (lb *Leaderboard) Entries() []LeaderboardEntry {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return slices.Clone(lb.entries)
+}
+
+// Close stops refreshing the leaderboard and removes its floating text from the world.
+func (lb *Leaderboard) Close() {
+	lb.once.Do(func() {
+		close(lb.done)
+		lb.mu.Lock()
+		h := lb.h
+		lb.h = nil
+		lb.mu.Unlock()
+		w := lb.world()
+		if h == nil || w == nil {
+			return
+		}
+		w.Exec(func(tx *world.Tx) {
+			if e, ok := lb.text(tx, h); ok {
+				tx.RemoveEntity(e)
+				if e.H() != h {
+					_ = e.H().Close()
+				}
+			}
+			_ = h.Close()
+		})
+	})
+}
+
+// refreshLoop refreshes the leaderboard every refresh interval until it is closed.
+func (lb *Leaderboard) refreshLoop() {
+	t := time.NewTicker(lb.conf.RefreshInterval)
+	defer t.Stop()
+
+	lb.refresh()
+	for {
+		select {
+		case <-t.C:
+			lb.refresh()
+		case <-lb.done:
+			return
+		}
+	}
+}
+
+// refresh computes the entries of the leaderboard and schedules an update of its floating text.
+func (lb *Leaderboard) refresh() {
+	entries := lb.records.rank(lb.conf)
+	content := lb.render(entries)
+
+	lb.mu.Lock()
+	lb.entries = entries
+	lb.mu.Unlock()
+
+	w := lb.world()
+	if w == nil {
+		return
+	}
+	w.Exec(func(tx *world.Tx) {
+		lb.mu.Lock()
+		defer lb.mu.Unlock()
+		select {
+		case <-lb.done:
+			return
+		default:
+		}
+		if lb.h != nil {
+			if e, ok := lb.text(tx, lb.h); ok {
+				if e.H() != lb.h {
+					_ = lb.h.Close()
+					lb.h = e.H()
+				}
+				e.SetNameTag(content)
+				return
+			}
+			_ = lb.h.Close()
+		}
+		lb.h = entity.NewText(content, lb.conf.Position)
+		tx.AddEntity(lb.h)
+	})
+}
+
+// text returns the floating text of the leaderboard that was added with the handle passed. If the chunk of the
+// text was unloaded in the meantime, the text was reloaded with a new handle, so the text at the position of the
+// leaderboard is returned instead and any duplicates of it are removed.
+func (lb *Leaderboard) text(tx *world.Tx, h *world.EntityHandle) (*entity.Ent, bool) {
+	if e, ok := h.Entity(tx); ok {
+		return e.(*entity.Ent), true
+	}
+	pos := lb.conf.Position
+	// Reading a block loads the chunk, along with the entities stored in it.
+	tx.Block(cube.PosFromVec3(pos))
+
+	var texts []*entity.Ent
+	for e := range tx.EntitiesWithin(cube.Box(pos[0], pos[1], pos[2], pos[0], pos[1], pos[2]).Grow(0.5)) {
+		if ent, ok := e.(*entity.Ent); ok && ent.H().Type() == entity.TextType {
+			texts = append(texts, ent)
+		}
+	}
+	if len(texts) == 0 {
+		return nil, false
+	}
+	for _, dup := range texts[1:] {
+		tx.RemoveEntity(dup)
+		_ = dup.H().Close()
+	}
+	return texts[0], true
+}
+
+// world returns the world that the leaderboard is shown in.
+func (lb *Leaderboard) world() *world.World {
+	if lb.w != nil {
+		return lb.w
+	}
+	return DefaultWaitingWorld
+}
+
+// render renders the entries passed to the text shown on the leaderboard.
+func (lb *Leaderboard) render(entries []LeaderboardEntry) string {
+	var sb strings.Builder
+	sb.WriteString(text.Colourf("<gold><b>%s</b></gold>", lb.conf.Title))
+	if len(entries) == 0 {
+		sb.WriteString(text.Colourf("\n<grey>No entries yet</grey>"))
+	}
+	for i, e := range entries {
+		value := fmt.Sprintf("%.0f", e.Value)
+		if lb.conf.Metric == LeaderboardWinRate {
+			value = fmt.Sprintf("%.1f%%", e.Value)
+		}
+		sb.WriteString(text.Colourf("\n<yellow>#%d</yellow> <white>%s</white> <grey>-</grey> <aqua>%s</aqua>", i+1, e.Name, value))
+	}
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"github.com/df-mc/dragonfly/server/world"
+	"time"
+)
+
+// Lifecycle holds the timings of the lifecycle of a game. Zero values of TickRate, FinishDuration and MapPreload
+// are replaced with their defaults. Zero values of the other fields disable the feature they control.
+type Lifecycle struct {
+	// TickRate is the amount of times per second that the game is ticked. Defaults to 20.
+	TickRate int
+	// FinishDuration is the duration that the game stays in the finished State before it is closed. Defaults to
+	// 3 seconds.
+	FinishDuration time.Duration
+	// MapPreload is how long before the game starts that the map is selected and its world starts loading in the
+	// background. Defaults to 4 seconds.
+	MapPreload time.Duration
+	// FullCountdown is the duration that the countdown is shortened to once the game reaches its maximum amount of
+	// players. The countdown is never extended by this value.
+	FullCountdown time.Duration
+	// FillRatio is the ratio of participants to the maximum amount of players at which the countdown is shortened
+	// to FillCountdown, for example 0.75.
+	FillRatio float64
+	// FillCountdown is the duration that the countdown is shortened to once the game reaches FillRatio.
+	FillCountdown time.Duration
+	// FrozenDuration is the duration after the start of the game during which participants cannot move or
+	// attack each other.
+	FrozenDuration time.Duration
+	// MaxDuration is the maximum duration of the game. Once it is exceeded, the game is ended automatically.
+	MaxDuration time.Duration
+}
+
+// withDefaults returns the lifecycle with its zero values replaced with their defaults.
+func (l Lifecycle) withDefaults() Lifecycle {
+	if l.TickRate <= 0 {
+		l.TickRate = 20
+	}
+	if l.FinishDuration <= 0 {
+		l.FinishDuration = time.Second * 3
+	}
+	if l.MapPreload <= 0 {
+		l.MapPreload = time.Second * 4
+	}
+	return l
+}
+
+// ticks converts the duration passed to an amount of game ticks.
+func (l Lifecycle) ticks(d time.Duration) uint64 {
+	return uint64(d.Seconds() * float64(l.TickRate))
+}
+
+// UnfreezeHandler may be implemented by an Impl to be notified when the frozen phase at the start of the game
+// ends.
+type UnfreezeHandler interface {
+	// HandleUnfreeze is called when participants are able to move and attack each other.
+	HandleUnfreeze(tx *world.Tx)
+}
+
+// Lifecycle returns the lifecycle timings of the game.
+func (g *Game) Lifecycle() Lifecycle {
+	return g.lifecycle
+}
+
+// Frozen returns whether the game is in the frozen phase at its start, during which participants cannot move or
+// attack each other.
+func (g *Game) Frozen() bool {
+	return g.State().Playing() && g.playingTicks.Load() < g.lifecycle.ticks(g.lifecycle.FrozenDuration)
+}
+
+// Elapsed returns how long the game has been playing. It returns 0 if the game has not started yet.
+func (g *Game) Elapsed() time.Duration {
+	return time.Duration(g.playingTicks.Load()) * time.Second / time.Duration(g.lifecycle.TickRate)
+}
+
+// tickLifecycle handles the frozen phase and maximum duration of the game. It is called every tick while the
+// game is playing.
+func (g *Game) tickLifecycle(tx *world.Tx) {
+	ticks := g.playingTicks.Add(1)
+	if frozen := g.lifecycle.ticks(g.lifecycle.FrozenDuration); frozen > 0 && ticks == frozen {
+		if h, ok := g.impl.(UnfreezeHandler); ok {
+			h.HandleUnfreeze(tx)
+		}
+	}
+	if g.lifecycle.MaxDuration > 0 && ticks >= g.lifecycle.ticks(g.lifecycle.MaxDuration) && !g.checkWin(tx) {
+		g.log.Info("game reached its maximum duration", "duration", g.lifecycle.MaxDuration)
+		g.End(tx, Result{Reason: EndReasonTimeout})
+	}
+}
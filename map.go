@@ -1,15 +1,102 @@
 package game
 
 import (
+	"errors"
+	"fmt"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/go-gl/mathgl/mgl64"
 	"gopkg.in/yaml.v3"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 )
 
 type Map struct {
 	Name      string
 	WorldPath string
 	configRaw []byte
+
+	// Spawns are the spawn points that participants are teleported to when the game starts. If empty,
+	// participants keep the position they had in the waiting world.
+	Spawns []Spawn
+	// TeamSpawns are the spawn points of teams, keyed by the name of the team. Participants in a team with a
+	// spawn point are teleported to it instead of one of the Spawns.
+	TeamSpawns map[string]Spawn
+	// SpectatorSpawn is the position that participants are teleported to when they become spectators. If nil,
+	// spectators stay where they are.
+	SpectatorSpawn *Spawn
+	// WorldBorder is the area that players are confined to while playing. If nil, the map has no border.
+	WorldBorder *WorldBorder
+	// MinPlayers and MaxPlayers override the values returned by the Impl once the map is selected, or from the
+	// start if the game is pinned to the map. A value of 0 means that the value of the Impl is used.
+	MinPlayers, MaxPlayers int
+	// Weight is the relative chance of the map being selected by WeightedRandom. Defaults to 1.
+	Weight float64
+
+	// DisplayName is the name of the map shown to players. If empty, Name is shown.
+	DisplayName string
+	// Author is the name of the builder of the map. It may be empty.
+	Author string
+	// Description is a short description of the map. It may be empty.
+	Description string
+	// Icon is the path to a texture in a resource pack or the URL of an image that is shown in the vote form.
+	// It may be empty.
+	Icon string
+	// Tags are free-form labels of the map, such as "small" or "large", that may be used to build variants of a
+	// game mode from the same maps directory.
+	Tags []string
+	// WaitingRoom holds the waiting-room features of the map if it is a lobby map. Lobby maps are never played
+	// on.
+	WaitingRoom *WaitingRoom
+
+	templateOnce sync.Once
+	template     *worldTemplate
+	templateErr  error
+}
+
+// Title returns the name of the map that is shown to players: its DisplayName, or Name if it has none.
+func (m *Map) Title() string {
+	if m.DisplayName != "" {
+		return m.DisplayName
+	}
+	return m.Name
+}
+
+// HasTags returns whether the map has all the tags passed.
+func (m *Map) HasTags(tags ...string) bool {
+	for _, t := range tags {
+		if !slices.Contains(m.Tags, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Spawn is a position and rotation that a player may be teleported to.
+type Spawn struct {
+	Position mgl64.Vec3
+	Rotation cube.Rotation
+}
+
+// Teleport teleports the player passed to the spawn and rotates it to face the direction of the spawn.
+func (s Spawn) Teleport(p *player.Player) {
+	p.Teleport(s.Position)
+	r := p.Rotation()
+	p.Move(mgl64.Vec3{}, s.Rotation.Yaw()-r.Yaw(), s.Rotation.Pitch()-r.Pitch())
+}
+
+// WorldBorder is a square area centred around a point that players are not able to leave.
+type WorldBorder struct {
+	Center mgl64.Vec2
+	Radius float64
+}
+
+// Contains returns whether the position passed is within the world border.
+func (b WorldBorder) Contains(pos mgl64.Vec3) bool {
+	return pos.X() >= b.Center.X()-b.Radius && pos.X() <= b.Center.X()+b.Radius &&
+		pos.Z() >= b.Center.Y()-b.Radius && pos.Z() <= b.Center.Y()+b.Radius
 }
 
 func (m *Map) CopyWorldTo(path string) error {
@@ -20,13 +107,167 @@ func (m *Map) UnmarshalConfig(v any) error {
 	return yaml.Unmarshal(m.configRaw, v)
 }
 
-func loadMaps(dir string) ([]*Map, error) {
+// mapConfig is the part of the config.yml of a map that is understood by the game itself.
+type mapConfig struct {
+	Spawns         []spawnConfig          `yaml:"spawns"`
+	TeamSpawns     map[string]spawnConfig `yaml:"team_spawns"`
+	SpectatorSpawn *spawnConfig           `yaml:"spectator_spawn"`
+	WorldBorder    *struct {
+		X      float64 `yaml:"x"`
+		Z      float64 `yaml:"z"`
+		Radius float64 `yaml:"radius"`
+	} `yaml:"world_border"`
+	MinPlayers int      `yaml:"min_players"`
+	MaxPlayers int      `yaml:"max_players"`
+	Weight     *float64 `yaml:"weight"`
+
+	DisplayName string   `yaml:"display_name"`
+	Author      string   `yaml:"author"`
+	Description string   `yaml:"description"`
+	Icon        string   `yaml:"icon"`
+	Tags        []string `yaml:"tags"`
+
+	Lobby *waitingRoomConfig `yaml:"lobby"`
+}
+
+// spawnConfig is the representation of a Spawn in the config.yml of a map.
+type spawnConfig struct {
+	X     float64 `yaml:"x"`
+	Y     float64 `yaml:"y"`
+	Z     float64 `yaml:"z"`
+	Yaw   float64 `yaml:"yaw"`
+	Pitch float64 `yaml:"pitch"`
+}
+
+// spawn converts the spawn config to a Spawn.
+func (c spawnConfig) spawn() Spawn {
+	return Spawn{
+		Position: mgl64.Vec3{c.X, c.Y, c.Z},
+		Rotation: cube.Rotation{c.Yaw, c.Pitch},
+	}
+}
+
+// parseConfig parses the raw config of the map into its typed fields, returning an error if the config is
+// invalid.
+func (m *Map) parseConfig() error {
+	var conf mapConfig
+	if err := yaml.Unmarshal(m.configRaw, &conf); err != nil {
+		return err
+	}
+
+	if conf.MinPlayers < 0 || conf.MaxPlayers < 0 {
+		return errors.New("min_players and max_players must not be negative")
+	}
+	if conf.MaxPlayers > 0 && conf.MinPlayers > conf.MaxPlayers {
+		return fmt.Errorf("min_players (%d) must not be greater than max_players (%d)", conf.MinPlayers, conf.MaxPlayers)
+	}
+	if conf.MaxPlayers > 0 && len(conf.Spawns) > 0 && len(conf.TeamSpawns) == 0 && len(conf.Spawns) < conf.MaxPlayers {
+		return fmt.Errorf("map has %d spawns but max_players is %d", len(conf.Spawns), conf.MaxPlayers)
+	}
+	if conf.WorldBorder != nil && conf.WorldBorder.Radius <= 0 {
+		return errors.New("world_border radius must be positive")
+	}
+	if conf.Weight != nil && *conf.Weight < 0 {
+		return errors.New("weight must not be negative")
+	}
+
+	m.MinPlayers, m.MaxPlayers = conf.MinPlayers, conf.MaxPlayers
+	m.DisplayName, m.Author, m.Description, m.Icon = conf.DisplayName, conf.Author, conf.Description, conf.Icon
+	m.Tags = conf.Tags
+	if conf.Lobby != nil {
+		room, err := conf.Lobby.waitingRoom()
+		if err != nil {
+			return fmt.Errorf("lobby: %w", err)
+		}
+		m.WaitingRoom = room
+	}
+	m.Weight = 1
+	if conf.Weight != nil {
+		m.Weight = *conf.Weight
+	}
+	m.Spawns = make([]Spawn, 0, len(conf.Spawns))
+	for _, s := range conf.Spawns {
+		m.Spawns = append(m.Spawns, s.spawn())
+	}
+	m.TeamSpawns = make(map[string]Spawn, len(conf.TeamSpawns))
+	for name, s := range conf.TeamSpawns {
+		m.TeamSpawns[name] = s.spawn()
+	}
+	if conf.SpectatorSpawn != nil {
+		s := conf.SpectatorSpawn.spawn()
+		m.SpectatorSpawn = &s
+	}
+	if conf.WorldBorder != nil {
+		m.WorldBorder = &WorldBorder{
+			Center: mgl64.Vec2{conf.WorldBorder.X, conf.WorldBorder.Z},
+			Radius: conf.WorldBorder.Radius,
+		}
+	}
+
+	if !m.spawnsInBorder() {
+		return errors.New("all spawns must be within the world_border")
+	}
+	return nil
+}
+
+// spawnsInBorder returns whether all spawns of the map are within its world border.
+func (m *Map) spawnsInBorder() bool {
+	if m.WorldBorder == nil {
+		return true
+	}
+	for _, s := range m.Spawns {
+		if !m.WorldBorder.Contains(s.Position) {
+			return false
+		}
+	}
+	for _, s := range m.TeamSpawns {
+		if !m.WorldBorder.Contains(s.Position) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateTeams returns an error if the map has team spawns for teams that do not exist in the game, or is
+// missing a spawn for one of the teams.
+func (m *Map) validateTeams(teams []TeamConfig) error {
+	if len(m.TeamSpawns) == 0 {
+		return nil
+	}
+	names := make(map[string]struct{}, len(teams))
+	for _, t := range teams {
+		names[t.Name] = struct{}{}
+		if _, ok := m.TeamSpawns[t.Name]; !ok {
+			return fmt.Errorf("map %s: missing team spawn for team %s", m.Name, t.Name)
+		}
+	}
+	for name := range m.TeamSpawns {
+		if _, ok := names[name]; !ok {
+			return fmt.Errorf("map %s: team spawn for unknown team %s", m.Name, name)
+		}
+	}
+	return nil
+}
+
+// validateSpawns returns an error if the map has fewer spawns than the maximum amount of players passed, so that
+// participants would have to share spawns. Maps without spawns or with team spawns are not checked.
+func (m *Map) validateSpawns(maxPlayers int) error {
+	if len(m.Spawns) == 0 || len(m.TeamSpawns) > 0 || len(m.Spawns) >= maxPlayers {
+		return nil
+	}
+	return fmt.Errorf("map %s: has %d spawns but up to %d players", m.Name, len(m.Spawns), maxPlayers)
+}
+
+// loadMaps loads all maps in the directory passed. Maps that fail to load are left out and their errors are
+// returned keyed by the name of the map. An error is only returned if the directory itself could not be read.
+func loadMaps(dir string) ([]*Map, map[string]error, error) {
 	dirs, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	maps := make([]*Map, 0, len(dirs))
+	errs := make(map[string]error)
 	for _, d := range dirs {
 		if !d.IsDir() {
 			continue
@@ -38,14 +279,20 @@ func loadMaps(dir string) ([]*Map, error) {
 		}
 		configRaw, err := os.ReadFile(filepath.Join(dir, d.Name(), "config.yml"))
 		if err != nil {
-			return nil, err
+			errs[d.Name()] = err
+			continue
 		}
-		maps = append(maps, &Map{
+		m := &Map{
 			Name:      d.Name(),
 			WorldPath: worldPath,
 			configRaw: configRaw,
-		})
+		}
+		if err := m.parseConfig(); err != nil {
+			errs[d.Name()] = fmt.Errorf("invalid config: %w", err)
+			continue
+		}
+		maps = append(maps, m)
 	}
 
-	return maps, nil
+	return maps, errs, nil
 }
@@ -0,0 +1,210 @@
+package game
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MapRegistry holds the maps of a maps directory. Maps are loaded and validated once and shared by all games
+// that use the registry, instead of every game reading the directory again.
+type MapRegistry struct {
+	dir     string
+	log     *slog.Logger
+	preload bool
+
+	mu          sync.RWMutex
+	maps        []*Map
+	kits        []*Kit
+	errs        map[string]error
+	fingerprint string
+
+	done chan struct{}
+	once sync.Once
+}
+
+// MapRegistryConfig is a configuration of a MapRegistry.
+type MapRegistryConfig struct {
+	// Dir is the maps directory. Every directory in it that contains a world directory and a config.yml is a map.
+	// Kits may be defined in a kits.yml file in the directory.
+	Dir string
+	// Log is the logger that load errors of maps are logged to. If nil, slog.Default is used.
+	Log *slog.Logger
+	// WatchInterval is how often the maps directory is checked for changes, reloading the maps if anything
+	// changed. If 0, maps are only reloaded when Reload is called.
+	WatchInterval time.Duration
+	// PreloadWorlds specifies whether the worlds of all maps are loaded into memory in the background whenever
+	// the maps are loaded, so that games using InstanceMemory start without delay.
+	PreloadWorlds bool
+}
+
+// New creates a MapRegistry and loads the maps in its directory. An error is returned only if the directory
+// itself could not be read; maps that fail to load are reported by Errors.
+func (c MapRegistryConfig) New() (*MapRegistry, error) {
+	r := &MapRegistry{dir: c.Dir, log: c.Log, preload: c.PreloadWorlds, done: make(chan struct{})}
+	if r.log == nil {
+		r.log = slog.Default()
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	if c.WatchInterval > 0 {
+		go r.watch(c.WatchInterval)
+	}
+	return r, nil
+}
+
+// Dir returns the maps directory of the registry.
+func (r *MapRegistry) Dir() string {
+	return r.dir
+}
+
+// Maps returns the maps of the registry that loaded successfully, ordered by name.
+func (r *MapRegistry) Maps() []*Map {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.maps)
+}
+
+// Map returns the map with the name passed, if it loaded successfully.
+func (r *MapRegistry) Map(name string) (*Map, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, m := range r.maps {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// Kits returns the kits defined in the kits.yml file of the maps directory, in the order they are defined. If the
+// file failed to load, no kits are returned and the error is reported by Errors.
+func (r *MapRegistry) Kits() []*Kit {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.kits)
+}
+
+// Errors returns the errors of the maps that failed to load in the last reload, keyed by the name of the map. An
+// error of the kits.yml file is keyed by kits.yml.
+func (r *MapRegistry) Errors() map[string]error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	errs := make(map[string]error, len(r.errs))
+	for name, err := range r.errs {
+		errs[name] = err
+	}
+	return errs
+}
+
+// Reload reads all maps in the directory of the registry again. Games that were already created keep the maps
+// they were created with; games created afterwards use the reloaded maps. An error is returned only if the
+// directory itself could not be read, in which case the maps of the registry are left unchanged.
+func (r *MapRegistry) Reload() error {
+	fingerprint, err := r.scan()
+	if err != nil {
+		return fmt.Errorf("failed to read maps directory: %w", err)
+	}
+	maps, errs, err := loadMaps(r.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read maps directory: %w", err)
+	}
+	for name, err := range errs {
+		r.log.Error("failed to load map", "map", name, "error", err)
+	}
+	kits, err := loadKits(r.dir)
+	if err != nil {
+		r.log.Error("failed to load kits", "error", err)
+		errs[kitsFile] = err
+	}
+
+	r.mu.Lock()
+	r.maps, r.kits, r.errs, r.fingerprint = maps, kits, errs, fingerprint
+	r.mu.Unlock()
+	r.log.Info("loaded maps", "dir", r.dir, "maps", len(maps), "errors", len(errs))
+
+	if r.preload {
+		go r.preloadWorlds(maps)
+	}
+	return nil
+}
+
+// preloadWorlds loads the worlds of the maps passed into memory one by one.
+func (r *MapRegistry) preloadWorlds(maps []*Map) {
+	for _, m := range maps {
+		if err := m.PreloadWorld(); err != nil {
+			r.log.Error("failed to preload world", "map", m.Name, "error", err)
+		}
+	}
+}
+
+// Close stops watching the maps directory for changes.
+func (r *MapRegistry) Close() {
+	r.once.Do(func() {
+		close(r.done)
+	})
+}
+
+// watch reloads the maps every interval if the maps directory changed, until the registry is closed.
+func (r *MapRegistry) watch(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			fingerprint, err := r.scan()
+			if err != nil {
+				continue
+			}
+			r.mu.RLock()
+			changed := fingerprint != r.fingerprint
+			r.mu.RUnlock()
+			if !changed {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				r.log.Error("failed to reload maps", "error", err)
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// scan returns a fingerprint of the maps directory made of the names of the maps and the modification times of
+// their config.yml and world directory. The fingerprint changes when a map is added, removed or edited.
+func (r *MapRegistry) scan() (string, error) {
+	dirs, err := os.ReadDir(r.dir)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		sb.WriteString(d.Name())
+		for _, name := range []string{"config.yml", "world"} {
+			if stat, err := os.Stat(filepath.Join(r.dir, d.Name(), name)); err == nil {
+				fmt.Fprintf(&sb, ":%d", stat.ModTime().UnixNano())
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	if stat, err := os.Stat(filepath.Join(r.dir, kitsFile)); err == nil {
+		fmt.Fprintf(&sb, "%s:%d\n", kitsFile, stat.ModTime().UnixNano())
+	}
+	return sb.String(), nil
+}
+
+// MapRegistry returns the MapRegistry that the game loads its maps from.
+func (g *Game) MapRegistry() *MapRegistry {
+	return g.registry
+}
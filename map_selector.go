@@ -0,0 +1,217 @@
+package game
+
+import (
+	"cmp"
+	"github.com/df-mc/dragonfly/server/world"
+	"math/rand"
+	"slices"
+	"sync"
+)
+
+// MapSelection holds the information that a MapSelector selects a map with.
+type MapSelection struct {
+	// Maps are the maps that may be selected. It is never empty.
+	Maps []*Map
+	// Votes holds the amount of votes of every map, in the same order as Maps.
+	Votes []int
+	// Players is the amount of participants in the game.
+	Players int
+}
+
+// filter returns the selection with only the maps for which keep returns true. If no maps would be left, the
+// selection is returned unchanged.
+func (s MapSelection) filter(keep func(m *Map) bool) MapSelection {
+	filtered := MapSelection{Players: s.Players}
+	for i, m := range s.Maps {
+		if keep(m) {
+			filtered.Maps = append(filtered.Maps, m)
+			filtered.Votes = append(filtered.Votes, s.Votes[i])
+		}
+	}
+	if len(filtered.Maps) == 0 {
+		return s
+	}
+	return filtered
+}
+
+// MapSelector selects the map that a game is played on. A MapSelector may keep state between games, such as the
+// maps played recently, so the same selector should be shared by all games of a mode.
+type MapSelector interface {
+	// Select returns the map that the game is played on.
+	Select(s MapSelection) *Map
+}
+
+// VoteSelector is a MapSelector that selects the map with the most votes. Ties, including nobody voting, are
+// resolved randomly.
+type VoteSelector struct{}
+
+// Select ...
+func (VoteSelector) Select(s MapSelection) *Map {
+	maxVotes := slices.Max(s.Votes)
+	candidates := make([]*Map, 0, len(s.Maps))
+	for i, v := range s.Votes {
+		if v == maxVotes {
+			candidates = append(candidates, s.Maps[i])
+		}
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+// WeightedRandom is a MapSelector that selects a random map, where the chance of every map is proportional to the
+// weight set in its config.yml. Votes are ignored.
+type WeightedRandom struct{}
+
+// Select ...
+func (WeightedRandom) Select(s MapSelection) *Map {
+	var total float64
+	for _, m := range s.Maps {
+		total += m.Weight
+	}
+	if total <= 0 {
+		return s.Maps[rand.Intn(len(s.Maps))]
+	}
+	r := rand.Float64() * total
+	for _, m := range s.Maps {
+		if r < m.Weight {
+			return m
+		}
+		r -= m.Weight
+	}
+	return s.Maps[len(s.Maps)-1]
+}
+
+// RoundRobin is a MapSelector that selects every map in turn, ordered by name. Votes are ignored.
+type RoundRobin struct {
+	mu   sync.Mutex
+	last string
+}
+
+// Select ...
+func (r *RoundRobin) Select(s MapSelection) *Map {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	maps := slices.SortedFunc(slices.Values(s.Maps), func(a, b *Map) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	next := maps[0]
+	for _, m := range maps {
+		if m.Name > r.last {
+			next = m
+			break
+		}
+	}
+	r.last = next.Name
+	return next
+}
+
+// RecentExclusion is a MapSelector that excludes the maps selected in the last N games before selecting a map
+// using Next. If all maps were played recently, no maps are excluded.
+type RecentExclusion struct {
+	// N is the amount of recent games whose maps are excluded.
+	N int
+	// Next selects the map from the maps that were not excluded. If nil, VoteSelector is used.
+	Next MapSelector
+
+	mu     sync.Mutex
+	recent []string
+}
+
+// Select ...
+func (r *RecentExclusion) Select(s MapSelection) *Map {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s = s.filter(func(m *Map) bool {
+		return !slices.Contains(r.recent, m.Name)
+	})
+	m := selectWith(r.Next, s)
+	if r.N > 0 {
+		r.recent = append(r.recent, m.Name)
+		if len(r.recent) > r.N {
+			r.recent = r.recent[len(r.recent)-r.N:]
+		}
+	}
+	return m
+}
+
+// PlayerCountAware is a MapSelector that excludes maps whose configured minimum amount of players exceeds the
+// amount of participants, or whose maximum is lower, before selecting a map using Next.
+type PlayerCountAware struct {
+	// Next selects the map from the maps that were not excluded. If nil, VoteSelector is used.
+	Next MapSelector
+}
+
+// Select ...
+func (p PlayerCountAware) Select(s MapSelection) *Map {
+	s = s.filter(func(m *Map) bool {
+		return m.MinPlayers <= s.Players && (m.MaxPlayers == 0 || m.MaxPlayers >= s.Players)
+	})
+	return selectWith(p.Next, s)
+}
+
+// selectWith selects a map using the selector passed, or VoteSelector if it is nil.
+func selectWith(sel MapSelector, s MapSelection) *Map {
+	if sel == nil {
+		return VoteSelector{}.Select(s)
+	}
+	return sel.Select(s)
+}
+
+// MapVetoer may be implemented by an Impl to prevent maps from being voted for and selected.
+type MapVetoer interface {
+	// VetoMap returns true if the map passed should not be played.
+	VetoMap(m *Map) bool
+}
+
+// Ballot returns the maps that players of the game are able to vote for.
+func (g *Game) Ballot() []*Map {
+	return g.withoutVetoed(nil, g.ballot)
+}
+
+// withoutVetoed returns the maps passed without the maps vetoed by the Impl of the game. If the Impl panics, the
+// game is aborted and no maps are returned. The transaction passed may be nil if none is available.
+func (g *Game) withoutVetoed(tx *world.Tx, maps []*Map) (kept []*Map) {
+	vetoer, ok := g.impl.(MapVetoer)
+	if !ok {
+		return maps
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			kept = nil
+			g.handlePanic(tx, r)
+		}
+	}()
+	return slices.DeleteFunc(slices.Clone(maps), vetoer.VetoMap)
+}
+
+// Votes returns the amount of votes of every map that participants of the game voted for. Votes of participants
+// that left are not counted.
+func (g *Game) Votes() map[*Map]int {
+	votes := make(map[*Map]int, len(g.ballot))
+	for par := range g.Participants() {
+		if par.vote != nil {
+			votes[par.vote]++
+		}
+	}
+	return votes
+}
+
+// newBallot chooses the maps that players of the game are able to vote for, excluding maps vetoed by the Impl. It
+// is called while the Impl is loaded, so that a panic in the Impl fails loading the game.
+func (g *Game) newBallot() []*Map {
+	ballot := slices.Clone(g.availableMaps)
+	if vetoer, ok := g.impl.(MapVetoer); ok {
+		ballot = slices.DeleteFunc(ballot, vetoer.VetoMap)
+	}
+	rand.Shuffle(len(ballot), func(i, j int) {
+		ballot[i], ballot[j] = ballot[j], ballot[i]
+	})
+	if g.ballotSize > 0 && len(ballot) > g.ballotSize {
+		ballot = ballot[:g.ballotSize]
+	}
+	slices.SortFunc(ballot, func(a, b *Map) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return ballot
+}
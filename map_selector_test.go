@@ -0,0 +1,45 @@
+package game
+
+import (
+	"fmt"
+	"github.com/akmalfairuz/df-game/internal"
+	"testing"
+)
+
+// gameWithParticipants returns a waiting game with the maps passed on its ballot and the amount of participants
+// passed, who all voted for the map passed.
+func gameWithParticipants(maps []*Map, participants int, vote *Map) *Game {
+	g := &Game{
+		availableMaps: maps,
+		ballot:        maps,
+		participants:  internal.NewMap[string, *Participant](),
+	}
+	for i := range participants {
+		xuid := fmt.Sprint(i)
+		g.participants.Store(xuid, &Participant{xuid: xuid, vote: vote})
+	}
+	return g
+}
+
+func TestSelectMapSkipsMapsTooSmallForParticipants(t *testing.T) {
+	small := &Map{Name: "small", MaxPlayers: 2}
+	large := &Map{Name: "large", MaxPlayers: 8}
+	g := gameWithParticipants([]*Map{small, large}, 4, small)
+
+	m, err := g.selectMap(nil)
+	if err != nil {
+		t.Fatalf("selectMap returned an error: %v", err)
+	}
+	if m != large {
+		t.Fatalf("selectMap selected %s for 4 players, want large", m.Name)
+	}
+}
+
+func TestSelectMapFailsWithoutFittingMap(t *testing.T) {
+	small := &Map{Name: "small", MaxPlayers: 2}
+	g := gameWithParticipants([]*Map{small}, 3, small)
+
+	if m, err := g.selectMap(nil); err == nil {
+		t.Fatalf("selectMap selected %s for 3 players, want an error", m.Name)
+	}
+}
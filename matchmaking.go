@@ -0,0 +1,146 @@
+package game
+
+import (
+	"cmp"
+	"github.com/google/uuid"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// MatchRequest is a request of one or more players to be placed in a game.
+type MatchRequest struct {
+	// XUIDs are the XUIDs of the players that want to join a game together.
+	XUIDs []string
+	// Map is the name of the map that the players queued for. If empty, the players have no preference.
+	Map string
+}
+
+// Size returns the amount of players in the request.
+func (r MatchRequest) Size() int {
+	return len(r.XUIDs)
+}
+
+// Lobby is a snapshot of a game in the waiting State that a MatchmakingStrategy may place players in. Lobbies
+// are plain values so that strategies can be tested without running games.
+type Lobby struct {
+	// Game is the game that the lobby is a snapshot of.
+	Game *Game
+	// ID is the ID of the game.
+	ID uuid.UUID
+	// Players is the amount of participants in the game.
+	Players int
+	// MinPlayers and MaxPlayers are the minimum and maximum amount of players of the game.
+	MinPlayers, MaxPlayers int
+	// StartingIn is the amount of seconds until the game starts, or -1 if the countdown is not running.
+	StartingIn int
+	// PinnedMap is the name of the map that the game will be played on regardless of votes, or empty if the map
+	// is chosen normally.
+	PinnedMap string
+	// XUIDs are the XUIDs of the participants of the game.
+	XUIDs []string
+}
+
+// Free returns the amount of players that can still join the lobby.
+func (l Lobby) Free() int {
+	return l.MaxPlayers - l.Players
+}
+
+// MatchmakingStrategy decides which games players are placed in when they join through a Factory.
+type MatchmakingStrategy interface {
+	// Rank returns the lobbies passed that the request may be placed in, in the order that they should be tried.
+	// Lobbies that are left out are not tried. If none of the lobbies accept the request, a new game is created.
+	// All lobbies passed have room for the whole request.
+	Rank(req MatchRequest, lobbies []Lobby) []Lobby
+}
+
+// FirstFit is a MatchmakingStrategy that tries the lobbies in random order.
+type FirstFit struct{}
+
+// Rank ...
+func (FirstFit) Rank(_ MatchRequest, lobbies []Lobby) []Lobby {
+	rand.Shuffle(len(lobbies), func(i, j int) {
+		lobbies[i], lobbies[j] = lobbies[j], lobbies[i]
+	})
+	return lobbies
+}
+
+// MostPopulated is a MatchmakingStrategy that fills the lobbies with the most players first, so that as few
+// lobbies as possible are half empty.
+type MostPopulated struct{}
+
+// Rank ...
+func (MostPopulated) Rank(_ MatchRequest, lobbies []Lobby) []Lobby {
+	slices.SortStableFunc(lobbies, func(a, b Lobby) int {
+		return cmp.Compare(b.Players, a.Players)
+	})
+	return lobbies
+}
+
+// AboutToStart is a MatchmakingStrategy that prefers lobbies whose countdown is running and closest to starting,
+// followed by the lobbies with the most players.
+type AboutToStart struct{}
+
+// Rank ...
+func (AboutToStart) Rank(_ MatchRequest, lobbies []Lobby) []Lobby {
+	slices.SortStableFunc(lobbies, func(a, b Lobby) int {
+		aCounting, bCounting := a.StartingIn >= 0, b.StartingIn >= 0
+		switch {
+		case aCounting && !bCounting:
+			return -1
+		case !aCounting && bCounting:
+			return 1
+		case aCounting && bCounting && a.StartingIn != b.StartingIn:
+			return cmp.Compare(a.StartingIn, b.StartingIn)
+		}
+		return cmp.Compare(b.Players, a.Players)
+	})
+	return lobbies
+}
+
+// SkillBalanced is a MatchmakingStrategy that places players in the lobby whose average rating is closest to the
+// average rating of the request. Empty lobbies are tried last.
+type SkillBalanced struct {
+	// Rating returns the rating of the player with the XUID passed. If nil, all players have the same rating.
+	Rating func(xuid string) float64
+}
+
+// Rank ...
+func (s SkillBalanced) Rank(req MatchRequest, lobbies []Lobby) []Lobby {
+	target := s.average(req.XUIDs)
+	distance := func(l Lobby) float64 {
+		if len(l.XUIDs) == 0 {
+			return math.Inf(1)
+		}
+		return math.Abs(s.average(l.XUIDs) - target)
+	}
+	slices.SortStableFunc(lobbies, func(a, b Lobby) int {
+		return cmp.Compare(distance(a), distance(b))
+	})
+	return lobbies
+}
+
+// average returns the average rating of the players with the XUIDs passed.
+func (s SkillBalanced) average(xuids []string) float64 {
+	if len(xuids) == 0 || s.Rating == nil {
+		return 0
+	}
+	var sum float64
+	for _, xuid := range xuids {
+		sum += s.Rating(xuid)
+	}
+	return sum / float64(len(xuids))
+}
+
+// MapQueue is a MatchmakingStrategy that only places players in lobbies pinned to the map they queued for. Players
+// without a preferred map are placed in lobbies that are not pinned to a map. Lobbies are filled most populated
+// first.
+type MapQueue struct{}
+
+// Rank ...
+func (MapQueue) Rank(req MatchRequest, lobbies []Lobby) []Lobby {
+	lobbies = slices.DeleteFunc(lobbies, func(l Lobby) bool {
+		return l.PinnedMap != req.Map
+	})
+	return MostPopulated{}.Rank(req, lobbies)
+}
@@ -0,0 +1,86 @@
+package game
+
+import (
+	"github.com/google/uuid"
+	"slices"
+	"testing"
+)
+
+// lobby returns a fake lobby with the amount of players, countdown and pinned map passed.
+func lobby(players, startingIn int, pinnedMap string, xuids ...string) Lobby {
+	return Lobby{
+		ID:         uuid.New(),
+		Players:    players,
+		MinPlayers: 2,
+		MaxPlayers: 8,
+		StartingIn: startingIn,
+		PinnedMap:  pinnedMap,
+		XUIDs:      xuids,
+	}
+}
+
+// order returns the amount of players of the lobbies passed, to compare the order of ranked lobbies.
+func order(lobbies []Lobby) []int {
+	players := make([]int, 0, len(lobbies))
+	for _, l := range lobbies {
+		players = append(players, l.Players)
+	}
+	return players
+}
+
+func request(xuids ...string) MatchRequest {
+	return MatchRequest{XUIDs: xuids}
+}
+
+func TestFirstFitKeepsAllLobbies(t *testing.T) {
+	lobbies := []Lobby{lobby(1, -1, ""), lobby(2, -1, ""), lobby(3, -1, "")}
+	ranked := FirstFit{}.Rank(request("a"), slices.Clone(lobbies))
+	got := order(ranked)
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("FirstFit ranked lobbies with players %v, want all lobbies", got)
+	}
+}
+
+func TestMostPopulated(t *testing.T) {
+	lobbies := []Lobby{lobby(1, -1, ""), lobby(5, -1, ""), lobby(3, -1, "")}
+	if got := order(MostPopulated{}.Rank(request("a"), lobbies)); !slices.Equal(got, []int{5, 3, 1}) {
+		t.Fatalf("MostPopulated ranked lobbies with players %v, want [5 3 1]", got)
+	}
+}
+
+func TestAboutToStart(t *testing.T) {
+	lobbies := []Lobby{lobby(6, -1, ""), lobby(2, 20, ""), lobby(3, 5, ""), lobby(1, -1, "")}
+	if got := order(AboutToStart{}.Rank(request("a"), lobbies)); !slices.Equal(got, []int{3, 2, 6, 1}) {
+		t.Fatalf("AboutToStart ranked lobbies with players %v, want [3 2 6 1]", got)
+	}
+}
+
+func TestSkillBalanced(t *testing.T) {
+	ratings := map[string]float64{"a": 1500, "low": 1100, "mid": 1450, "high": 2000}
+	s := SkillBalanced{Rating: func(xuid string) float64 {
+		return ratings[xuid]
+	}}
+	lobbies := []Lobby{lobby(0, -1, ""), lobby(1, -1, "", "high"), lobby(2, -1, "", "mid"), lobby(3, -1, "", "low")}
+	if got := order(s.Rank(request("a"), lobbies)); !slices.Equal(got, []int{2, 3, 1, 0}) {
+		t.Fatalf("SkillBalanced ranked lobbies with players %v, want [2 3 1 0]", got)
+	}
+}
+
+func TestSkillBalancedWithoutRating(t *testing.T) {
+	lobbies := []Lobby{lobby(0, -1, ""), lobby(1, -1, "", "b"), lobby(2, -1, "", "c")}
+	if got := order(SkillBalanced{}.Rank(request("a"), lobbies)); !slices.Equal(got, []int{1, 2, 0}) {
+		t.Fatalf("SkillBalanced without Rating ranked lobbies with players %v, want [1 2 0]", got)
+	}
+}
+
+func TestMapQueue(t *testing.T) {
+	lobbies := []Lobby{lobby(1, -1, "castle"), lobby(4, -1, ""), lobby(3, -1, "castle"), lobby(2, -1, "forest")}
+	req := MatchRequest{XUIDs: []string{"a"}, Map: "castle"}
+	if got := order(MapQueue{}.Rank(req, slices.Clone(lobbies))); !slices.Equal(got, []int{3, 1}) {
+		t.Fatalf("MapQueue ranked lobbies with players %v for castle, want [3 1]", got)
+	}
+	if got := order(MapQueue{}.Rank(request("a"), slices.Clone(lobbies))); !slices.Equal(got, []int{4}) {
+		t.Fatalf("MapQueue ranked lobbies with players %v without map, want [4]", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/df-mc/dragonfly/server/player"
 	"github.com/df-mc/dragonfly/server/world"
 	"sync/atomic"
+	"time"
 )
 
 type Participant struct {
@@ -13,7 +14,25 @@ type Participant struct {
 	state  ParticipantState
 	closed atomic.Bool
 
-	voteMapIndex *int
+	vote *Map
+	kit  *Kit
+
+	team   *Team
+	spawn  *Spawn
+	rating float64
+	score  int
+
+	parkour *parkourRun
+
+	lives            int
+	respawnTick      uint64
+	respawnMode      world.GameMode
+	protectedUntil   uint64
+	lastAttacker     *Participant
+	lastAttackedTick uint64
+
+	snapshot          *playerSnapshot
+	disconnectedUntil time.Time
 
 	impl ParticipantImpl
 }
@@ -25,6 +44,9 @@ func (par *Participant) State() ParticipantState {
 
 // Player returns the player of the participant. If the participant is not a player, the second return value is false.
 func (par *Participant) Player(tx *world.Tx) (*player.Player, bool) {
+	if par.h == nil {
+		return nil, false
+	}
 	p, ok := par.h.Entity(tx)
 	if !ok {
 		return nil, false
@@ -41,6 +63,32 @@ func (par *Participant) MustPlayer(tx *world.Tx) *player.Player {
 	return p
 }
 
+// Kit returns the kit that the participant selected, if any.
+func (par *Participant) Kit() (*Kit, bool) {
+	return par.kit, par.kit != nil
+}
+
+// Team returns the team of the participant. If the participant is not in a team, the second return value is false.
+func (par *Participant) Team() (*Team, bool) {
+	t := par.team
+	return t, t != nil
+}
+
+// SameTeam returns whether the participant is in the same team as the participant passed.
+func (par *Participant) SameTeam(other *Participant) bool {
+	t := par.team
+	return t != nil && t == other.team
+}
+
+// Spawn returns the spawn of the map that was assigned to the participant when the game started. If no spawn
+// was assigned, the second return value is false.
+func (par *Participant) Spawn() (Spawn, bool) {
+	if par.spawn == nil {
+		return Spawn{}, false
+	}
+	return *par.spawn, true
+}
+
 // Impl returns the implementation of the participant.
 func (par *Participant) Impl() ParticipantImpl {
 	return par.impl
@@ -0,0 +1,173 @@
+package game
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"sync"
+	"time"
+)
+
+// PartyInviteDuration is the duration that an invite to a party is valid for.
+var PartyInviteDuration = time.Minute
+
+// Party is a group of players that queue for games together. A party always has a leader, which is the only
+// member that is able to invite and kick other members.
+type Party struct {
+	mu sync.Mutex
+
+	id      uuid.UUID
+	leader  *Session
+	members []*Session
+	invites map[string]time.Time
+
+	disbanded bool
+}
+
+// NewParty creates a new party with the session passed as its leader. An error is returned if the session is
+// already in a party.
+func NewParty(leader *Session) (*Party, error) {
+	pt := &Party{
+		id:      uuid.New(),
+		leader:  leader,
+		members: []*Session{leader},
+		invites: make(map[string]time.Time),
+	}
+	if !leader.setParty(nil, pt) {
+		return nil, errors.New("player is already in a party")
+	}
+	return pt, nil
+}
+
+// ID returns the ID of the party.
+func (pt *Party) ID() uuid.UUID {
+	return pt.id
+}
+
+// Leader returns the session of the leader of the party.
+func (pt *Party) Leader() *Session {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return pt.leader
+}
+
+// Members returns the sessions of all members of the party, including the leader.
+func (pt *Party) Members() []*Session {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return slices.Clone(pt.members)
+}
+
+// Len returns the amount of members in the party, including the leader.
+func (pt *Party) Len() int {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return len(pt.members)
+}
+
+// Disbanded returns whether the party was disbanded.
+func (pt *Party) Disbanded() bool {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return pt.disbanded
+}
+
+// Invite invites the session passed to the party. The invite may be accepted using Accept until it expires after
+// PartyInviteDuration.
+func (pt *Party) Invite(s *Session) error {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	if pt.disbanded {
+		return errors.New("party is disbanded")
+	}
+	if slices.Contains(pt.members, s) {
+		return errors.New("player is already in the party")
+	}
+	pt.invites[s.xuid] = time.Now().Add(PartyInviteDuration)
+	return nil
+}
+
+// Invited returns whether the session passed has a pending invite to the party.
+func (pt *Party) Invited(s *Session) bool {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	expiry, ok := pt.invites[s.xuid]
+	return ok && time.Now().Before(expiry)
+}
+
+// Accept accepts a pending invite of the session passed, adding it to the party. An error is returned if the
+// session was not invited, the invite expired or the session is already in a party.
+func (pt *Party) Accept(s *Session) error {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	if pt.disbanded {
+		return errors.New("party is disbanded")
+	}
+	expiry, ok := pt.invites[s.xuid]
+	if !ok {
+		return errors.New("player was not invited to the party")
+	}
+	delete(pt.invites, s.xuid)
+	if time.Now().After(expiry) {
+		return errors.New("invite expired")
+	}
+	if !s.setParty(nil, pt) {
+		return errors.New("player is already in a party")
+	}
+	pt.members = append(pt.members, s)
+	return nil
+}
+
+// Kick removes the session passed from the party. The leader of the party cannot be kicked.
+func (pt *Party) Kick(s *Session) error {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	if s == pt.leader {
+		return errors.New("the leader cannot be kicked from the party")
+	}
+	if !pt.removeMember(s) {
+		return errors.New("player is not in the party")
+	}
+	return nil
+}
+
+// Leave removes the session passed from the party. If the session is the leader, the member that joined the
+// party first becomes the new leader. If no members are left, the party is disbanded.
+func (pt *Party) Leave(s *Session) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	if !pt.removeMember(s) {
+		return
+	}
+	if len(pt.members) == 0 {
+		pt.disbanded = true
+		return
+	}
+	if s == pt.leader {
+		pt.leader = pt.members[0]
+	}
+}
+
+// Disband disbands the party, removing all members from it.
+func (pt *Party) Disband() {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	for _, m := range pt.members {
+		m.setParty(pt, nil)
+	}
+	pt.members = nil
+	pt.invites = nil
+	pt.disbanded = true
+}
+
+// removeMember removes the session passed from the members of the party. It returns false if the session was
+// not a member.
+func (pt *Party) removeMember(s *Session) bool {
+	i := slices.Index(pt.members, s)
+	if i == -1 {
+		return false
+	}
+	pt.members = slices.Delete(pt.members, i, i+1)
+	s.setParty(pt, nil)
+	return true
+}
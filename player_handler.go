@@ -3,6 +3,7 @@ package game
 import (
 	"github.com/df-mc/dragonfly/server/block/cube"
 	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/entity"
 	"github.com/df-mc/dragonfly/server/item"
 	"github.com/df-mc/dragonfly/server/player"
 	"github.com/df-mc/dragonfly/server/player/skin"
@@ -35,6 +36,25 @@ func phExec(p *player.Player, f func(g *Game)) {
 
 func (ph *PlayerHandler) HandleMove(ctx *player.Context, newPos mgl64.Vec3, newRot cube.Rotation) {
 	phExec(ctx.Val(), func(g *Game) {
+		if g.State().Waiting() && g.handleWaitingMove(ctx.Val(), newPos) {
+			ctx.Cancel()
+			return
+		}
+		if g.State().Playing() && g.m.WorldBorder != nil && !g.m.WorldBorder.Contains(newPos) {
+			ctx.Cancel()
+			return
+		}
+		if g.State().Playing() && g.handlePlayingMove(ctx.Val(), newPos) {
+			ctx.Cancel()
+			return
+		}
+		if g.Frozen() {
+			pos := ctx.Val().Position()
+			if pos.X() != newPos.X() || pos.Z() != newPos.Z() {
+				ctx.Cancel()
+				return
+			}
+		}
 		g.ph.HandleMove(ctx, newPos, newRot)
 	})
 }
@@ -93,22 +113,53 @@ func (ph *PlayerHandler) HandleHeal(ctx *player.Context, health *float64, src wo
 
 func (ph *PlayerHandler) HandleHurt(ctx *player.Context, damage *float64, immune bool, attackImmunity *time.Duration, src world.DamageSource) {
 	phExec(ctx.Val(), func(g *Game) {
+		if g.State().Waiting() && g.practiceHurt(ctx, *damage, src) {
+			if !ctx.Cancelled() {
+				g.ph.HandleHurt(ctx, damage, immune, attackImmunity, src)
+			}
+			return
+		}
 		if !g.State().Playing() {
 			ctx.Cancel()
 			return
 		}
+		if g.Frozen() {
+			ctx.Cancel()
+			return
+		}
+		if proj, ok := src.(entity.ProjectileDamageSource); ok && g.friendlyFireSuppressed(proj.Owner, ctx.Val()) {
+			ctx.Cancel()
+			return
+		}
+		if par, ok := g.participants.Load(ctx.Val().XUID()); ok && g.Protected(par) {
+			ctx.Cancel()
+			return
+		}
 		g.ph.HandleHurt(ctx, damage, immune, attackImmunity, src)
+		if !ctx.Cancelled() {
+			g.handlePlayingHurt(ctx, *damage, src)
+		}
 	})
 }
 
 func (ph *PlayerHandler) HandleDeath(p *player.Player, src world.DamageSource, keepInv *bool) {
 	phExec(p, func(g *Game) {
+		if g.State().Playing() {
+			if victim, ok := g.participants.Load(p.XUID()); ok {
+				killer := g.killCredit(victim, src)
+				g.recordDeath(victim, killer)
+				g.events.publish(ParticipantDied{Game: g, Tx: p.Tx(), Victim: victim, Killer: killer})
+			}
+		}
 		g.ph.HandleDeath(p, src, keepInv)
 	})
 }
 
 func (ph *PlayerHandler) HandleRespawn(p *player.Player, pos *mgl64.Vec3, w **world.World) {
 	phExec(p, func(g *Game) {
+		if par, ok := g.participants.Load(p.XUID()); ok && g.State().Playing() && par.state.Playing() {
+			g.applyKit(p, par)
+		}
 		g.ph.HandleRespawn(p, pos, w)
 	})
 }
@@ -170,23 +221,9 @@ func (ph *PlayerHandler) HandleBlockPick(ctx *player.Context, pos cube.Pos, b wo
 func (ph *PlayerHandler) HandleItemUse(ctx *player.Context) {
 	phExec(ctx.Val(), func(g *Game) {
 		heldItem, _ := ctx.Val().HeldItems()
-		val, ok := heldItem.Value("gameItem")
-		if ok {
+		if g.useHotbarItem(ctx.Val(), heldItem) {
 			ctx.Cancel()
-			switch val.(string) {
-			case voteMapItemValue:
-				sendVoteMapForm(g, ctx.Val())
-				return
-			case quitItemValue:
-				_, _ = g.Leave(ctx.Val())
-				return
-			case playAgainItemValue:
-				g.playAgain(ctx.Val())
-				return
-			case teleporterItemValue:
-				sendTeleporterForm(g, ctx.Val())
-				return
-			}
+			return
 		}
 
 		g.ph.HandleItemUse(ctx)
@@ -235,10 +272,21 @@ func (ph *PlayerHandler) HandleItemConsume(ctx *player.Context, item item.Stack)
 
 func (ph *PlayerHandler) HandleAttackEntity(ctx *player.Context, e world.Entity, force, height *float64, critical *bool) {
 	phExec(ctx.Val(), func(g *Game) {
+		if target, ok := e.(*player.Player); ok && g.State().Waiting() && g.practiceHit(ctx.Val(), target) {
+			g.ph.HandleAttackEntity(ctx, e, force, height, critical)
+			return
+		}
 		if !g.State().Playing() {
 			ctx.Cancel()
 			return
 		}
+		if g.Frozen() || g.friendlyFireSuppressed(ctx.Val(), e) {
+			ctx.Cancel()
+			return
+		}
+		if par, ok := g.participants.Load(ctx.Val().XUID()); ok {
+			par.protectedUntil = 0
+		}
 		g.ph.HandleAttackEntity(ctx, e, force, height, critical)
 	})
 }
@@ -313,11 +361,17 @@ func (ph *PlayerHandler) HandleCommandExecution(ctx *player.Context, command cmd
 
 func (ph *PlayerHandler) HandleQuit(p *player.Player) {
 	phExec(p, func(g *Game) {
+		if g.disconnect(p) {
+			return
+		}
 		_, _ = g.Leave(p)
 	})
 
 	sess, ok := globalSessionManager.Load(p.XUID())
 	if ok {
+		if pt, ok := sess.Party(); ok {
+			pt.Leave(sess)
+		}
 		sess.Close()
 		globalSessionManager.Delete(p.XUID())
 	}
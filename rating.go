@@ -0,0 +1,216 @@
+package game
+
+import (
+	"math"
+)
+
+// EloConfig is a configuration of the Elo ratings of participants. Ratings are stored per game mode in the
+// StatsStore of the game, so games with an EloConfig must have a StatsStore.
+type EloConfig struct {
+	// K is the maximum change in rating for a single game. Defaults to 32.
+	K float64
+	// Initial is the rating of players that have not played a rated game yet. Defaults to 1000.
+	Initial float64
+}
+
+// withDefaults returns the config with its zero values replaced with their defaults.
+func (c EloConfig) withDefaults() EloConfig {
+	if c.K <= 0 {
+		c.K = 32
+	}
+	if c.Initial <= 0 {
+		c.Initial = 1000
+	}
+	return c
+}
+
+// RatingFunc returns a function that returns the rating of a player in the game mode passed, as stored in the
+// store passed. It may be used as the Rating function of SkillBalanced.
+func RatingFunc(store StatsStore, mode string, conf EloConfig) func(xuid string) float64 {
+	conf = conf.withDefaults()
+	return func(xuid string) float64 {
+		s, err := store.Stats(mode, xuid)
+		if err != nil {
+			return conf.Initial
+		}
+		return s.rating(conf)
+	}
+}
+
+// rating returns the rating in the stats, or the initial rating of the config passed if the stats have no rating.
+func (s Stats) rating(conf EloConfig) float64 {
+	if !s.Rated {
+		return conf.Initial
+	}
+	return s.Rating
+}
+
+// ratedLeaver is a participant that left the game while it was playing. Leavers are rated as placing last when the
+// game ends, against the participants that stayed.
+type ratedLeaver struct {
+	xuid   string
+	rating float64
+}
+
+// eloUnit is a player or team that is rated as a whole.
+type eloUnit struct {
+	members   []*Participant
+	rating    float64
+	placement int
+}
+
+// expectedScore returns the expected score of a player with rating a against a player with rating b.
+func expectedScore(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// eloDeltas returns the change in rating of every unit passed, comparing every unit with every other unit by
+// their placement.
+func eloDeltas(units []eloUnit, k float64) []float64 {
+	deltas := make([]float64, len(units))
+	if len(units) < 2 {
+		return deltas
+	}
+	for i, a := range units {
+		var sum float64
+		for j, b := range units {
+			if i == j {
+				continue
+			}
+			score := 0.5
+			if a.placement < b.placement {
+				score = 1
+			} else if a.placement > b.placement {
+				score = 0
+			}
+			sum += score - expectedScore(a.rating, b.rating)
+		}
+		deltas[i] = k / float64(len(units)-1) * sum
+	}
+	return deltas
+}
+
+// Rating returns the rating of the participant in the game mode of its game. If the game has no Elo ratings,
+// 0 is returned.
+func (par *Participant) Rating() float64 {
+	return par.rating
+}
+
+// loadRating loads the rating of the participant passed from the StatsStore of the game.
+func (g *Game) loadRating(par *Participant) {
+	if g.elo == nil {
+		return
+	}
+	par.rating = g.elo.Initial
+	if s, err := g.stats.Stats(g.mode, par.xuid); err == nil {
+		par.rating = s.rating(*g.elo)
+	}
+}
+
+// recordLeaver remembers the participant passed as having left the game while playing, so that it is rated as
+// a loss when the game ends.
+func (g *Game) recordLeaver(par *Participant) {
+	if g.elo == nil {
+		return
+	}
+	g.leavers = append(g.leavers, ratedLeaver{xuid: par.xuid, rating: par.rating})
+}
+
+// updateRatings computes the new ratings of all participants and leavers of the game from the result passed and
+// stores them. Every leaver is rated as a unit of its own placed below all participants, so that the rating that
+// leavers lose is gained by the participants that stayed.
+func (g *Game) updateRatings(res Result) {
+	if g.elo == nil {
+		return
+	}
+
+	defer func() {
+		g.leavers = nil
+	}()
+
+	units := g.eloUnits(res)
+	if len(units) == 0 {
+		// Nobody stayed to gain the rating of the leavers, so they are rated as losing against an equal opponent.
+		for _, l := range g.leavers {
+			g.addRating(l.xuid, -g.elo.K*expectedScore(l.rating, l.rating))
+		}
+		return
+	}
+	last := 0
+	for _, u := range units {
+		last = max(last, u.placement)
+	}
+	leavers := make([]eloUnit, 0, len(g.leavers))
+	for _, l := range g.leavers {
+		leavers = append(leavers, eloUnit{rating: g.storedRating(l.xuid, l.rating), placement: last + 1})
+	}
+	deltas := eloDeltas(append(units, leavers...), g.elo.K)
+	for i, u := range units {
+		for _, par := range u.members {
+			par.rating += deltas[i]
+			g.addRating(par.xuid, deltas[i])
+		}
+	}
+	for i, l := range g.leavers {
+		g.addRating(l.xuid, deltas[len(units)+i])
+	}
+}
+
+// storedRating returns the rating stored for the player with the XUID passed, or the fallback passed if it could
+// not be read.
+func (g *Game) storedRating(xuid string, fallback float64) float64 {
+	s, err := g.stats.Stats(g.mode, xuid)
+	if err != nil {
+		return fallback
+	}
+	return s.rating(*g.elo)
+}
+
+// eloUnits groups the participants of the game into the units that are rated against each other. In games with
+// teams, every team is a unit rated by the average rating of its members.
+func (g *Game) eloUnits(res Result) []eloUnit {
+	placement := func(par *Participant) int {
+		if p, ok := res.Placement(par); ok {
+			return p
+		}
+		if res.Draw() || res.Won(par) {
+			return 1
+		}
+		return 2
+	}
+
+	var units []eloUnit
+	teams := make(map[*Team]int)
+	for par := range g.Participants() {
+		t, ok := par.Team()
+		if !ok {
+			units = append(units, eloUnit{members: []*Participant{par}, rating: par.rating, placement: placement(par)})
+			continue
+		}
+		i, ok := teams[t]
+		if !ok {
+			i = len(units)
+			teams[t] = i
+			units = append(units, eloUnit{placement: math.MaxInt})
+		}
+		units[i].members = append(units[i].members, par)
+		units[i].placement = min(units[i].placement, placement(par))
+	}
+	for i := range units {
+		var sum float64
+		for _, par := range units[i].members {
+			sum += par.rating
+		}
+		units[i].rating = sum / float64(len(units[i].members))
+	}
+	return units
+}
+
+// addRating adds the change in rating passed to the stored rating of the player with the XUID passed. The change
+// is applied to the current stored rating rather than the rating loaded when the player joined, so that ratings
+// changed by other games in the meantime are not overwritten.
+func (g *Game) addRating(xuid string, delta float64) {
+	g.updateStats(xuid, func(s *Stats) {
+		s.Rating, s.Rated = s.rating(*g.elo)+delta, true
+	})
+}
@@ -0,0 +1,273 @@
+package game
+
+import (
+	"errors"
+	"github.com/akmalfairuz/df-game/internal"
+	form "github.com/akmalfairuz/ez-form"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/entity/effect"
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"time"
+)
+
+// pendingRejoins holds the games that disconnected players are able to rejoin, keyed by XUID.
+var pendingRejoins = internal.NewMap[string, *Game]()
+
+// DisconnectHandler may be implemented by an Impl to be notified when a participant disconnects during the
+// game and is kept for the reconnect grace period, and when it reconnects.
+type DisconnectHandler interface {
+	// HandleDisconnect is called when a playing participant disconnects and is kept in the game until the
+	// reconnect grace period expires.
+	HandleDisconnect(tx *world.Tx, par *Participant)
+	// HandleReconnect is called when a disconnected participant rejoins the game.
+	HandleReconnect(tx *world.Tx, par *Participant)
+}
+
+// playerSnapshot holds the state of a player at the moment it disconnected, so that it may be restored when
+// the player rejoins.
+type playerSnapshot struct {
+	pos       mgl64.Vec3
+	rot       cube.Rotation
+	gameMode  world.GameMode
+	health    float64
+	maxHealth float64
+	food      int
+	inventory []item.Stack
+	armour    []item.Stack
+	offHand   item.Stack
+	effects   []effect.Effect
+}
+
+// snapshotPlayer creates a snapshot of the current state of the player passed.
+func snapshotPlayer(p *player.Player) *playerSnapshot {
+	_, offHand := p.HeldItems()
+	return &playerSnapshot{
+		pos:       p.Position(),
+		rot:       p.Rotation(),
+		gameMode:  p.GameMode(),
+		health:    p.Health(),
+		maxHealth: p.MaxHealth(),
+		food:      p.Food(),
+		inventory: p.Inventory().Slots(),
+		armour:    p.Armour().Slots(),
+		offHand:   offHand,
+		effects:   p.Effects(),
+	}
+}
+
+// apply restores the state of the snapshot on the player passed.
+func (s *playerSnapshot) apply(p *player.Player) {
+	resetPlayer(p)
+	p.SetGameMode(s.gameMode)
+	Spawn{Position: s.pos, Rotation: s.rot}.Teleport(p)
+	for i, it := range s.inventory {
+		_ = p.Inventory().SetItem(i, it)
+	}
+	if len(s.armour) == 4 {
+		p.Armour().Set(s.armour[0], s.armour[1], s.armour[2], s.armour[3])
+	}
+	mainHand, _ := p.HeldItems()
+	p.SetHeldItems(mainHand, s.offHand)
+	p.SetFood(s.food)
+
+	// Health is restored before effects are added, so that effects such as absorption do not change it. Lowering the
+	// maximum health lowers the health without hurting the player, after which the maximum health is restored.
+	p.SetMaxHealth(s.maxHealth)
+	p.Heal(s.maxHealth, ResetPlayerHealSource{})
+	p.SetMaxHealth(s.health)
+	p.SetMaxHealth(s.maxHealth)
+	for _, e := range s.effects {
+		p.AddEffect(e)
+	}
+}
+
+// RejoinableGame returns the game that the player with the XUID passed disconnected from and is still able to
+// rejoin.
+func RejoinableGame(xuid string) (*Game, bool) {
+	g, ok := pendingRejoins.Load(xuid)
+	if !ok || g.closed.Load() {
+		return nil, false
+	}
+	return g, true
+}
+
+// HandleRejoin should be called when a player joins the server, after its Session has been stored in the
+// GlobalSessionManager. If the player disconnected from a game that it is still able to rejoin, the player is
+// either moved back into the game directly if the game has AutoRejoin enabled, or sent a form asking whether it
+// wants to rejoin. HandleRejoin returns true if the player has a game to rejoin.
+func HandleRejoin(p *player.Player) bool {
+	g, ok := RejoinableGame(p.XUID())
+	if !ok {
+		return false
+	}
+
+	if g.autoRejoin {
+		if err := g.Rejoin(p); err != nil {
+			g.log.Warn("failed to rejoin player", "name", p.Name(), "error", err)
+			return false
+		}
+		return true
+	}
+	sendRejoinForm(g, p)
+	return true
+}
+
+// sendRejoinForm sends a form to the player passed asking whether it wants to rejoin the game passed.
+func sendRejoinForm(g *Game, p *player.Player) {
+	m := form.NewModal("Rejoin")
+	m.WithContent("You were disconnected from a running game. Do you want to rejoin it?")
+	m.WithButton1("Rejoin")
+	m.WithButton2("Leave")
+	m.WithCallback(func(p *player.Player, rejoin bool) {
+		if !rejoin {
+			g.forfeitDisconnected(p.XUID())
+			return
+		}
+		if err := g.Rejoin(p); err != nil {
+			p.Message(text.Colourf("<red>Unable to rejoin the game: %s</red>", err))
+		}
+	})
+	p.SendForm(m)
+}
+
+// disconnect keeps the participant of the player passed in the game as disconnected for the reconnect grace
+// period. It returns false if the participant is not kept, in which case the player should leave the game.
+func (g *Game) disconnect(p *player.Player) bool {
+	if g.reconnectGrace <= 0 || !g.State().Playing() || !g.ValidTx(p.Tx()) {
+		return false
+	}
+	par, ok := g.participants.Load(p.XUID())
+	if !ok || !par.state.Playing() {
+		return false
+	}
+
+	par.snapshot = snapshotPlayer(p)
+	par.state = ParticipantStateDisconnected
+	par.disconnectedUntil = time.Now().Add(g.reconnectGrace)
+	par.h = nil
+	pendingRejoins.Store(p.XUID(), g)
+
+	if sess, ok := globalSessionManager.Load(p.XUID()); ok {
+		sess.SetGame(nil)
+	}
+	if h, ok := g.impl.(DisconnectHandler); ok {
+		g.callImpl(p.Tx(), func() {
+			h.HandleDisconnect(p.Tx(), par)
+		})
+	}
+	g.log.Info("participant disconnected", "name", par.name, "grace", g.reconnectGrace)
+	return true
+}
+
+// Rejoin moves the player passed back into the game it disconnected from, restoring the state it had when it
+// disconnected. The player must not be in a game or in the world of the game. Rejoin returns once the player was
+// added to the world of the game.
+func (g *Game) Rejoin(p *player.Player) error {
+	if g.closed.Load() {
+		return ErrGameClosed
+	}
+	if !g.State().Playing() {
+		return ErrNotPlaying
+	}
+
+	sess, ok := globalSessionManager.Load(p.XUID())
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if _, ok := sess.Game(); ok {
+		return ErrAlreadyInGame
+	}
+
+	par, ok := g.participants.Load(p.XUID())
+	if !ok || !par.state.Disconnected() {
+		return errors.New("player is not disconnected from the game")
+	}
+	if !g.takePendingRejoin(p.XUID()) {
+		return errors.New("rejoin grace period expired")
+	}
+
+	sess.SetGame(g)
+	par.name = p.Name()
+	par.h = p.H()
+	par.state = ParticipantStatePlaying
+
+	h := p.Tx().RemoveEntity(p)
+	<-g.w.Exec(func(tx *world.Tx) {
+		defer g.recoverImpl(tx)
+		newP := tx.AddEntity(h).(*player.Player)
+		if par.snapshot != nil {
+			par.snapshot.apply(newP)
+			par.snapshot = nil
+		}
+		if h, ok := g.impl.(DisconnectHandler); ok {
+			g.callImpl(tx, func() {
+				h.HandleReconnect(tx, par)
+			})
+		}
+	})
+	g.log.Info("participant reconnected", "name", par.name)
+	return nil
+}
+
+// forfeitDisconnected removes the disconnected participant with the XUID passed from the game before the
+// reconnect grace period expires.
+func (g *Game) forfeitDisconnected(xuid string) {
+	if g.closed.Load() || g.w == nil {
+		return
+	}
+	g.w.Exec(func(tx *world.Tx) {
+		par, ok := g.participants.Load(xuid)
+		if !ok || !par.state.Disconnected() {
+			return
+		}
+		if g.takePendingRejoin(xuid) {
+			g.removeParticipant(tx, par)
+		}
+	})
+}
+
+// expireDisconnected removes all disconnected participants whose reconnect grace period has expired.
+func (g *Game) expireDisconnected(tx *world.Tx) {
+	now := time.Now()
+	for par := range g.Participants() {
+		if !par.state.Disconnected() || now.Before(par.disconnectedUntil) {
+			continue
+		}
+		if g.takePendingRejoin(par.xuid) {
+			g.removeParticipant(tx, par)
+		}
+	}
+}
+
+// takePendingRejoin removes the pending rejoin of the player with the XUID passed if it is for this game. It
+// returns false if the player has no pending rejoin for the game, for example because it already rejoined or
+// the grace period expired.
+func (g *Game) takePendingRejoin(xuid string) bool {
+	return pendingRejoins.DeleteFunc(xuid, func(pg *Game) bool {
+		return pg == g
+	})
+}
+
+// removeParticipant removes a participant that no longer has a player from the game.
+func (g *Game) removeParticipant(tx *world.Tx, par *Participant) {
+	g.callImpl(tx, func() {
+		g.impl.HandleQuit(tx, par)
+	})
+	g.events.publish(PlayerLeft{Game: g, Tx: tx, Participant: par})
+	if g.forfeitsOnLeave() {
+		g.recordGame(par, false, false)
+		g.recordLeaver(par)
+		g.eliminate(par)
+	}
+	if t, ok := par.Team(); ok {
+		t.remove(par)
+	}
+	g.participants.Delete(par.xuid)
+	par.snapshot = nil
+	par.close()
+	g.checkWin(tx)
+}
@@ -0,0 +1,238 @@
+package game
+
+import (
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/title"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"math/rand/v2"
+	"time"
+)
+
+// RespawnConfig is the configuration of the death handling of a game. Participants of a game with a RespawnConfig
+// never actually die: lethal damage is cancelled and the participant either respawns or is eliminated, without
+// the PlayerHandler of the game being notified of a death. ParticipantDied is published instead.
+type RespawnConfig struct {
+	// Lives is the amount of lives that participants start with. A participant that loses its last life is
+	// eliminated and becomes a spectator. If 0, participants have infinite lives.
+	Lives int
+	// Delay is the duration that participants spectate after dying before they respawn, during which a countdown
+	// is shown. If 0, participants respawn immediately.
+	Delay time.Duration
+	// Protection is the duration after respawning during which participants cannot be hurt. Protection ends early
+	// when the participant attacks.
+	Protection time.Duration
+}
+
+// defaultKillCreditWindow is the default duration after being hurt by a participant during which a death is
+// credited to that participant.
+const defaultKillCreditWindow = time.Second * 10
+
+// ParticipantRespawned is published when a participant respawned after dying.
+type ParticipantRespawned struct {
+	Game        *Game
+	Tx          *world.Tx
+	Participant *Participant
+}
+
+// ParticipantEliminated is published when a participant lost its last life and became a spectator. Killer is the
+// participant credited with the final kill, or nil if nobody is.
+type ParticipantEliminated struct {
+	Game        *Game
+	Tx          *world.Tx
+	Participant *Participant
+	Killer      *Participant
+}
+
+func (ParticipantRespawned) event()  {}
+func (ParticipantEliminated) event() {}
+
+// Lives returns the amount of lives that the participant has left. It returns 0 if the game has no lives.
+func (par *Participant) Lives() int {
+	return par.lives
+}
+
+// Respawning returns whether the participant died and is waiting to respawn.
+func (par *Participant) Respawning() bool {
+	return par.respawnTick != 0
+}
+
+// SetLives sets the amount of lives that the participant passed has left, for example to take away its respawns
+// once its bed is destroyed. Setting the lives of a game without lives has no effect.
+func (g *Game) SetLives(par *Participant, lives int) {
+	if g.respawn == nil || g.respawn.Lives == 0 {
+		return
+	}
+	par.lives = max(lives, 0)
+}
+
+// Protected returns whether the participant passed is protected from damage after respawning.
+func (g *Game) Protected(par *Participant) bool {
+	return g.playingTicks.Load() < par.protectedUntil
+}
+
+// trackAttack remembers the participant that dealt the damage of the source passed to the victim passed, so that
+// a later death of the victim may be credited to it.
+func (g *Game) trackAttack(victim *Participant, src world.DamageSource) {
+	if attacker := g.attacker(src); attacker != nil && attacker != victim {
+		victim.lastAttacker, victim.lastAttackedTick = attacker, g.playingTicks.Load()
+	}
+}
+
+// killCredit returns the participant credited with the death of the victim passed by the source passed: the
+// participant that dealt the damage, or otherwise the last participant that hurt the victim within the kill credit
+// window. Deaths by the void or fall damage after being knocked are therefore credited to the attacker.
+func (g *Game) killCredit(victim *Participant, src world.DamageSource) *Participant {
+	attacker, tick := victim.lastAttacker, victim.lastAttackedTick
+	victim.lastAttacker = nil
+	if killer := g.attacker(src); killer != nil && killer != victim {
+		return killer
+	}
+	if attacker == nil || attacker.Closed() || g.playingTicks.Load()-tick > g.lifecycle.ticks(g.creditWindow) {
+		return nil
+	}
+	if par, ok := g.participants.Load(attacker.xuid); !ok || par != attacker {
+		return nil
+	}
+	return attacker
+}
+
+// lethal returns whether the damage passed kills the player passed.
+func lethal(p *player.Player, damage float64) bool {
+	main, off := p.HeldItems()
+	if _, ok := main.Item().(item.Totem); ok {
+		return false
+	}
+	if _, ok := off.Item().(item.Totem); ok {
+		return false
+	}
+	return p.Health()-max(0, damage-p.Absorption()) <= mgl64.Epsilon
+}
+
+// handleDeath handles the death of the participant passed by the damage source passed when the game has a
+// RespawnConfig. The participant is eliminated if it lost its last life, or respawns after the delay otherwise.
+func (g *Game) handleDeath(p *player.Player, par *Participant, src world.DamageSource) {
+	killer := g.killCredit(par, src)
+	g.recordDeath(par, killer)
+	g.events.publish(ParticipantDied{Game: g, Tx: p.Tx(), Victim: par, Killer: killer})
+	if !g.State().Playing() || !par.state.Playing() {
+		return
+	}
+
+	if g.respawn.Lives > 0 {
+		par.lives--
+		if par.lives <= 0 {
+			par.lives = 0
+			p.SendTitle(title.New(text.Colourf("<red><b>ELIMINATED</b></red>")))
+			g.setSpectator(p)
+			g.events.publish(ParticipantEliminated{Game: g, Tx: p.Tx(), Participant: par, Killer: killer})
+			g.checkWin(p.Tx())
+			return
+		}
+	}
+
+	par.respawnMode = p.GameMode()
+	resetPlayer(p)
+	if g.respawn.Delay <= 0 {
+		g.respawnParticipant(p, par)
+		return
+	}
+	p.SetGameMode(world.GameModeSpectator)
+	if g.m.SpectatorSpawn != nil {
+		g.m.SpectatorSpawn.Teleport(p)
+	}
+	par.respawnTick = g.playingTicks.Load() + g.lifecycle.ticks(g.respawn.Delay)
+	g.sendRespawnTitle(p, par)
+}
+
+// tickRespawns respawns participants whose respawn delay passed and shows the countdown to the others. It is
+// called every tick while the game is playing.
+func (g *Game) tickRespawns(tx *world.Tx) {
+	if g.respawn == nil {
+		return
+	}
+	ticks := g.playingTicks.Load()
+	g.PlayingPlayers(tx, func(p *player.Player, par *Participant) {
+		if !par.Respawning() {
+			return
+		}
+		if ticks >= par.respawnTick {
+			g.respawnParticipant(p, par)
+			return
+		}
+		if (par.respawnTick-ticks)%uint64(g.lifecycle.TickRate) == 0 {
+			g.sendRespawnTitle(p, par)
+		}
+	})
+}
+
+// sendRespawnTitle shows the player passed how many seconds are left until it respawns.
+func (g *Game) sendRespawnTitle(p *player.Player, par *Participant) {
+	seconds := (par.respawnTick - g.playingTicks.Load()) / uint64(g.lifecycle.TickRate)
+	p.SendTitle(title.New(text.Colourf("<red><b>YOU DIED</b></red>")).
+		WithSubtitle(text.Colourf("<yellow>Respawning in %d...</yellow>", seconds)).
+		WithFadeInDuration(0).
+		WithDuration(time.Second * 2))
+}
+
+// respawnParticipant respawns the participant passed at its respawn point, giving it its kit and protection.
+func (g *Game) respawnParticipant(p *player.Player, par *Participant) {
+	par.respawnTick = 0
+	resetPlayer(p)
+	p.SetGameMode(par.respawnMode)
+	if spawn, ok := g.respawnPoint(par); ok {
+		spawn.Teleport(p)
+	} else {
+		p.Teleport(p.Tx().World().Spawn().Vec3Middle())
+	}
+	g.applyKit(p, par)
+	par.protectedUntil = g.playingTicks.Load() + g.lifecycle.ticks(g.respawn.Protection)
+	g.events.publish(ParticipantRespawned{Game: g, Tx: p.Tx(), Participant: par})
+}
+
+// respawnPoint returns the spawn that the participant passed respawns at: the spawn of its team, a random spawn of
+// the map, or the spawn it was assigned when the game started.
+func (g *Game) respawnPoint(par *Participant) (Spawn, bool) {
+	if t, ok := par.Team(); ok {
+		if spawn, ok := g.m.TeamSpawns[t.name]; ok {
+			return spawn, true
+		}
+	}
+	if len(g.m.Spawns) > 0 {
+		return g.m.Spawns[rand.IntN(len(g.m.Spawns))], true
+	}
+	return par.Spawn()
+}
+
+// handlePlayingHurt handles damage dealt to a playing participant. It returns true if the damage was turned into
+// a death by the RespawnConfig of the game, in which case the context is cancelled.
+func (g *Game) handlePlayingHurt(ctx *player.Context, damage float64, src world.DamageSource) bool {
+	par, ok := g.participants.Load(ctx.Val().XUID())
+	if !ok || !par.state.Playing() {
+		return false
+	}
+	g.trackAttack(par, src)
+	if g.respawn == nil || !lethal(ctx.Val(), damage) {
+		return false
+	}
+	ctx.Cancel()
+	g.handleDeath(ctx.Val(), par, src)
+	return true
+}
+
+// handlePlayingMove kills participants that fall into the void when the game has a RespawnConfig, instead of
+// waiting for the void to damage them. It returns true if the participant died.
+func (g *Game) handlePlayingMove(p *player.Player, pos mgl64.Vec3) bool {
+	if g.respawn == nil || pos.Y() >= float64(p.Tx().Range().Min()) {
+		return false
+	}
+	par, ok := g.participants.Load(p.XUID())
+	if !ok || !par.state.Playing() || par.Respawning() {
+		return false
+	}
+	g.handleDeath(p, par, entity.VoidDamageSource{})
+	return true
+}
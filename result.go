@@ -0,0 +1,118 @@
+package game
+
+import (
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/title"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"slices"
+)
+
+// EndReason is the reason that a game ended.
+type EndReason uint8
+
+const (
+	EndReasonUnknown EndReason = iota
+	// EndReasonElimination is used when all but the winners were eliminated.
+	EndReasonElimination
+	// EndReasonTimeout is used when the game reached its time limit.
+	EndReasonTimeout
+	// EndReasonForfeit is used when the opponents of the winners left the game.
+	EndReasonForfeit
+	// EndReasonScore is used when the winners reached the score needed to win.
+	EndReasonScore
+)
+
+// String returns a human-readable name of the reason.
+func (r EndReason) String() string {
+	switch r {
+	case EndReasonElimination:
+		return "elimination"
+	case EndReasonTimeout:
+		return "timeout"
+	case EndReasonForfeit:
+		return "forfeit"
+	case EndReasonScore:
+		return "score"
+	}
+	return "unknown"
+}
+
+// Result is the outcome of a game, passed to Game.End.
+type Result struct {
+	// Reason is the reason that the game ended.
+	Reason EndReason
+	// Winners are the participants that won the game. If WinningTeam is set and Winners is empty, the members of
+	// the team are used as winners. A Result without winners is a draw.
+	Winners []*Participant
+	// WinningTeam is the team that won the game, if any.
+	WinningTeam *Team
+	// Placements holds the placement of participants, keyed by their XUID, where 1 is the best placement.
+	// Participants may share a placement.
+	Placements map[string]int
+}
+
+// Draw returns whether nobody won the game.
+func (res Result) Draw() bool {
+	return len(res.Winners) == 0 && res.WinningTeam == nil
+}
+
+// Won returns whether the participant passed won the game.
+func (res Result) Won(par *Participant) bool {
+	if res.WinningTeam != nil && res.WinningTeam.Has(par) {
+		return true
+	}
+	return slices.Contains(res.Winners, par)
+}
+
+// Placement returns the placement of the participant passed. If the result holds no placement for the
+// participant, the second return value is false.
+func (res Result) Placement(par *Participant) (int, bool) {
+	placement, ok := res.Placements[par.xuid]
+	return placement, ok
+}
+
+// normalise fills the winners of the result with the members of the winning team if no winners were set.
+func (res Result) normalise() Result {
+	if len(res.Winners) == 0 && res.WinningTeam != nil {
+		res.Winners = slices.Collect(res.WinningTeam.Members())
+	}
+	return res
+}
+
+// ResultAnnouncer may be implemented by an Impl to replace the default victory and defeat titles shown to
+// players when the game ends.
+type ResultAnnouncer interface {
+	// AnnounceResult is called for every player in the game when the game ends.
+	AnnounceResult(p *player.Player, par *Participant, res Result)
+}
+
+// Result returns the result that the game ended with. If the game has not ended yet, the second return value
+// is false.
+func (g *Game) Result() (Result, bool) {
+	if g.result == nil {
+		return Result{}, false
+	}
+	return *g.result, true
+}
+
+// announceResult shows the result of the game to the player passed.
+func (g *Game) announceResult(p *player.Player, par *Participant, res Result) {
+	if announcer, ok := g.impl.(ResultAnnouncer); ok {
+		announcer.AnnounceResult(p, par, res)
+		return
+	}
+
+	var t title.Title
+	switch {
+	case res.Draw():
+		t = title.New(text.Colourf("<yellow><b>DRAW</b></yellow>"))
+	case res.Won(par):
+		t = title.New(text.Colourf("<gold><b>VICTORY!</b></gold>"))
+	default:
+		t = title.New(text.Colourf("<red><b>GAME OVER</b></red>"))
+	}
+	if placement, ok := res.Placement(par); ok {
+		t = t.WithSubtitle(text.Colourf("<grey>You placed #%d</grey>", placement))
+	}
+	p.SendTitle(t)
+}
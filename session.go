@@ -20,6 +20,8 @@ type Session struct {
 	name string
 	g    *Game
 	h    *world.EntityHandle
+
+	party *Party
 }
 
 // NewSession creates a new session for the player p.
@@ -62,6 +64,25 @@ func (s *Session) SetGame(g *Game) {
 	s.g = g
 }
 
+// Party returns the party that the session is in, if any.
+func (s *Session) Party() (*Party, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.party, s.party != nil
+}
+
+// setParty sets the party of the session to new if the current party of the session is old. It returns false if
+// the current party is not old.
+func (s *Session) setParty(old, new *Party) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.party != old {
+		return false
+	}
+	s.party = new
+	return true
+}
+
 // EntityHandle returns the entity handle of the player that the session is for.
 func (s *Session) EntityHandle() *world.EntityHandle {
 	return s.h
@@ -31,6 +31,7 @@ const (
 	ParticipantStateUnknown ParticipantState = iota
 	ParticipantStatePlaying
 	ParticipantStateSpectating
+	ParticipantStateDisconnected
 )
 
 func (state ParticipantState) Playing() bool {
@@ -41,6 +42,10 @@ func (state ParticipantState) Spectating() bool {
 	return state == ParticipantStateSpectating
 }
 
+func (state ParticipantState) Disconnected() bool {
+	return state == ParticipantStateDisconnected
+}
+
 func (state ParticipantState) Unknown() bool {
 	return state == ParticipantStateUnknown
 }
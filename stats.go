@@ -0,0 +1,289 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Stats are the statistics of a player in a game mode.
+type Stats struct {
+	// Name is the name of the player when its stats were last updated by a game.
+	Name        string         `json:"name,omitempty"`
+	Wins        int            `json:"wins"`
+	Losses      int            `json:"losses"`
+	Kills       int            `json:"kills"`
+	Deaths      int            `json:"deaths"`
+	GamesPlayed int            `json:"games_played"`
+	Playtime    time.Duration  `json:"playtime"`
+	Custom      map[string]int `json:"custom,omitempty"`
+	// Rating is the Elo rating of the player. Rated specifies whether the player has a rating, which is only the
+	// case after its first rated game.
+	Rating float64 `json:"rating,omitempty"`
+	Rated  bool    `json:"rated,omitempty"`
+}
+
+// clone returns a deep copy of the stats.
+func (s Stats) clone() Stats {
+	s.Custom = maps.Clone(s.Custom)
+	return s
+}
+
+// StatsStore stores the Stats of players per game mode, keyed by XUID.
+type StatsStore interface {
+	// Stats returns the stats of the player with the XUID passed in the game mode passed. If the player has no
+	// stats, zero Stats are returned.
+	Stats(mode, xuid string) (Stats, error)
+	// Update updates the stats of the player with the XUID passed in the game mode passed using the function
+	// passed.
+	Update(mode, xuid string, fn func(s *Stats)) error
+	// All returns the stats of all players in the game mode passed, keyed by XUID.
+	All(mode string) (map[string]Stats, error)
+	// Close closes the store, persisting any pending changes.
+	Close() error
+}
+
+// MemoryStatsStore is a StatsStore that keeps stats in memory only.
+type MemoryStatsStore struct {
+	mu    sync.Mutex
+	stats map[string]map[string]Stats
+}
+
+// NewMemoryStatsStore creates an empty MemoryStatsStore.
+func NewMemoryStatsStore() *MemoryStatsStore {
+	return &MemoryStatsStore{stats: make(map[string]map[string]Stats)}
+}
+
+// Stats ...
+func (m *MemoryStatsStore) Stats(mode, xuid string) (Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats[mode][xuid].clone(), nil
+}
+
+// Update ...
+func (m *MemoryStatsStore) Update(mode, xuid string, fn func(s *Stats)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	modeStats, ok := m.stats[mode]
+	if !ok {
+		modeStats = make(map[string]Stats)
+		m.stats[mode] = modeStats
+	}
+	s := modeStats[xuid].clone()
+	fn(&s)
+	modeStats[xuid] = s
+	return nil
+}
+
+// All ...
+func (m *MemoryStatsStore) All(mode string) (map[string]Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := make(map[string]Stats, len(m.stats[mode]))
+	for xuid, s := range m.stats[mode] {
+		all[xuid] = s.clone()
+	}
+	return all, nil
+}
+
+// Close ...
+func (m *MemoryStatsStore) Close() error {
+	return nil
+}
+
+// JSONStatsStore is a StatsStore that keeps stats in memory and periodically writes them to a JSON file.
+type JSONStatsStore struct {
+	*MemoryStatsStore
+
+	path  string
+	dirty chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// defaultFlushInterval is the interval at which a JSONStatsStore writes changes if no valid interval is passed.
+const defaultFlushInterval = time.Second * 30
+
+// NewJSONStatsStore opens the JSON stats file at the path passed, creating it if it does not exist. Changes are
+// written to the file every flushInterval and when the store is closed. If flushInterval is not positive, changes
+// are written every 30 seconds.
+func NewJSONStatsStore(path string, flushInterval time.Duration) (*JSONStatsStore, error) {
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	s := &JSONStatsStore{
+		MemoryStatsStore: NewMemoryStatsStore(),
+		path:             path,
+		dirty:            make(chan struct{}, 1),
+		done:             make(chan struct{}),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read stats file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.stats); err != nil {
+			return nil, fmt.Errorf("failed to decode stats file: %w", err)
+		}
+	}
+
+	s.wg.Add(1)
+	go s.flushLoop(flushInterval)
+	return s, nil
+}
+
+// Update ...
+func (s *JSONStatsStore) Update(mode, xuid string, fn func(s *Stats)) error {
+	if err := s.MemoryStatsStore.Update(mode, xuid, fn); err != nil {
+		return err
+	}
+	select {
+	case s.dirty <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close stops the periodic flushing of the store and writes all stats to the file.
+func (s *JSONStatsStore) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+	return s.flush()
+}
+
+// flushLoop writes the stats to the file every interval if they changed. It returns when the store is closed.
+func (s *JSONStatsStore) flushLoop(interval time.Duration) {
+	defer s.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			select {
+			case <-s.dirty:
+				_ = s.flush()
+			default:
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// flush writes all stats to the file, replacing it atomically.
+func (s *JSONStatsStore) flush() error {
+	s.mu.Lock()
+	data, err := json.Marshal(s.stats)
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode stats: %w", err)
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// writeFileAtomic writes the data passed to the file at the path passed by writing a temporary file and renaming
+// it, so that the file is never left partially written. The directory of the file is created if needed.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Stats returns the StatsStore of the game, if any.
+func (g *Game) Stats() (StatsStore, bool) {
+	return g.stats, g.stats != nil
+}
+
+// AddStat adds delta to the custom counter with the name passed of the participant passed.
+func (g *Game) AddStat(par *Participant, name string, delta int) {
+	g.updateStats(par.xuid, func(s *Stats) {
+		if s.Custom == nil {
+			s.Custom = make(map[string]int)
+		}
+		s.Custom[name] += delta
+	})
+}
+
+// updateStats updates the stats of the player with the XUID passed in the mode of the game, if the game has a
+// StatsStore.
+func (g *Game) updateStats(xuid string, fn func(s *Stats)) {
+	if g.stats == nil {
+		return
+	}
+	if err := g.stats.Update(g.mode, xuid, fn); err != nil {
+		g.log.Error("failed to update stats", "xuid", xuid, "error", err)
+	}
+}
+
+// forfeitsOnLeave returns true if participants that leave the game now are recorded as having lost it: while the
+// game is playing and not being aborted.
+func (g *Game) forfeitsOnLeave() bool {
+	return g.State().Playing() && !g.aborting
+}
+
+// recordGame records a played game in the stats of the participant passed. If won is false and the result is
+// not a draw, a loss is recorded.
+func (g *Game) recordGame(par *Participant, won, draw bool) {
+	playtime := g.Elapsed()
+	g.updateStats(par.xuid, func(s *Stats) {
+		s.Name = par.name
+		s.GamesPlayed++
+		s.Playtime += playtime
+		switch {
+		case won:
+			s.Wins++
+		case !draw:
+			s.Losses++
+		}
+	})
+}
+
+// recordDeath records the death of the participant passed, crediting a kill to the killer if it is not nil.
+func (g *Game) recordDeath(victim, killer *Participant) {
+	g.updateStats(victim.xuid, func(s *Stats) {
+		s.Name = victim.name
+		s.Deaths++
+	})
+	if killer != nil && killer != victim {
+		g.updateStats(killer.xuid, func(s *Stats) {
+			s.Name = killer.name
+			s.Kills++
+		})
+	}
+}
+
+// attacker returns the participant that dealt the damage of the source passed, if any.
+func (g *Game) attacker(src world.DamageSource) *Participant {
+	var e world.Entity
+	switch src := src.(type) {
+	case entity.AttackDamageSource:
+		e = src.Attacker
+	case entity.ProjectileDamageSource:
+		e = src.Owner
+	}
+	p, ok := e.(*player.Player)
+	if !ok {
+		return nil
+	}
+	par, _ := g.participants.Load(p.XUID())
+	return par
+}
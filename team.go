@@ -0,0 +1,334 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"github.com/akmalfairuz/df-game/internal"
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"iter"
+	"slices"
+)
+
+// TeamConfig is a configuration for a team of a game.
+type TeamConfig struct {
+	// Name is the name of the team, for example "Red".
+	Name string
+	// Colour is the colour of the team. It is used to colour the name of the team and the team selector items.
+	Colour item.Colour
+	// Capacity is the maximum amount of participants that can be in the team. If Capacity is 0 or lower, the team
+	// has no limit other than the maximum amount of players of the game.
+	Capacity int
+}
+
+// New creates a new team from the config.
+func (c TeamConfig) New() *Team {
+	return &Team{
+		name:     c.Name,
+		colour:   c.Colour,
+		capacity: c.Capacity,
+		members:  internal.NewMap[string, *Participant](),
+	}
+}
+
+// Team is a group of participants in a game that play together.
+type Team struct {
+	name     string
+	colour   item.Colour
+	capacity int
+	score    int
+
+	members *internal.Map[string, *Participant]
+}
+
+// Name returns the name of the team.
+func (t *Team) Name() string {
+	return t.name
+}
+
+// Colour returns the colour of the team.
+func (t *Team) Colour() item.Colour {
+	return t.colour
+}
+
+// TextColour returns the colour tag of the team that may be used in text.Colourf, for example "red".
+func (t *Team) TextColour() string {
+	return textColour(t.colour)
+}
+
+// FormattedName returns the name of the team coloured with the colour of the team.
+func (t *Team) FormattedName() string {
+	c := t.TextColour()
+	return text.Colourf("<%s>%s</%s>", c, t.name, c)
+}
+
+// Capacity returns the maximum amount of participants that can be in the team. A value of 0 or lower means that
+// the team has no limit.
+func (t *Team) Capacity() int {
+	return t.capacity
+}
+
+// Full returns whether the team has reached its capacity.
+func (t *Team) Full() bool {
+	return t.capacity > 0 && t.members.Len() >= t.capacity
+}
+
+// Members returns the participants in the team.
+func (t *Team) Members() iter.Seq[*Participant] {
+	return func(yield func(*Participant) bool) {
+		for _, par := range t.members.Map() {
+			if !yield(par) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the amount of participants in the team.
+func (t *Team) Len() int {
+	return t.members.Len()
+}
+
+// Score returns the score of the team, which is the sum of the points scored by its members.
+func (t *Team) Score() int {
+	return t.score
+}
+
+// PlayingMembers returns the participants in the team that are playing.
+func (t *Team) PlayingMembers() iter.Seq[*Participant] {
+	return func(yield func(*Participant) bool) {
+		for _, par := range t.members.Map() {
+			if par.state.Playing() {
+				if !yield(par) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// PlayingLen returns the amount of participants in the team that are playing.
+func (t *Team) PlayingLen() int {
+	var n int
+	for _, par := range t.members.Map() {
+		if par.state.Playing() {
+			n++
+		}
+	}
+	return n
+}
+
+// Has returns whether the participant passed is in the team.
+func (t *Team) Has(par *Participant) bool {
+	_, ok := t.members.Load(par.xuid)
+	return ok
+}
+
+// add adds the participant passed to the team.
+func (t *Team) add(par *Participant) {
+	t.members.Store(par.xuid, par)
+	par.team = t
+}
+
+// remove removes the participant passed from the team.
+func (t *Team) remove(par *Participant) {
+	t.members.Delete(par.xuid)
+	if par.team == t {
+		par.team = nil
+	}
+}
+
+// Teams returns the teams of the game.
+func (g *Game) Teams() iter.Seq[*Team] {
+	return func(yield func(*Team) bool) {
+		for _, t := range g.teams {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// TeamLen returns the amount of teams in the game.
+func (g *Game) TeamLen() int {
+	return len(g.teams)
+}
+
+// TeamByName returns the team with the name passed.
+func (g *Game) TeamByName(name string) (*Team, bool) {
+	for _, t := range g.teams {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// PlayingTeams returns the teams in the game that have at least one participant that is playing.
+func (g *Game) PlayingTeams() iter.Seq[*Team] {
+	return func(yield func(*Team) bool) {
+		for _, t := range g.teams {
+			if t.PlayingLen() > 0 {
+				if !yield(t) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// PlayingTeamLen returns the amount of teams in the game that have at least one participant that is playing.
+func (g *Game) PlayingTeamLen() int {
+	var n int
+	for _, t := range g.teams {
+		if t.PlayingLen() > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// SetTeam moves the participant passed to the team passed. If t is nil, the participant is removed from its
+// current team. An error is returned if the team is full or is not a team of the game.
+func (g *Game) SetTeam(par *Participant, t *Team) error {
+	if _, ok := g.participants.Load(par.xuid); !ok {
+		return errors.New("participant is not in the game")
+	}
+	if t != nil {
+		if !slices.Contains(g.teams, t) {
+			return errors.New("team is not in the game")
+		}
+		if t.Has(par) {
+			return nil
+		}
+		if t.Full() {
+			return fmt.Errorf("team %s is full", t.name)
+		}
+	}
+
+	if current, ok := par.Team(); ok {
+		current.remove(par)
+	}
+	if t != nil {
+		t.add(par)
+	}
+	return nil
+}
+
+// TeamPlayers are used to iterate over all players in the team passed, calling the function passed for each
+// player.
+func (g *Game) TeamPlayers(tx *world.Tx, t *Team, fn func(p *player.Player, par *Participant)) {
+	if !g.ValidTx(tx) {
+		return
+	}
+
+	for par := range t.Members() {
+		p, ok := par.Player(tx)
+		if !ok {
+			continue
+		}
+
+		fn(p, par)
+	}
+}
+
+// PlayingTeamPlayers are used to iterate over all players in the team passed that are playing, calling the
+// function passed for each player.
+func (g *Game) PlayingTeamPlayers(tx *world.Tx, t *Team, fn func(p *player.Player, par *Participant)) {
+	if !g.ValidTx(tx) {
+		return
+	}
+
+	for par := range t.PlayingMembers() {
+		p, ok := par.Player(tx)
+		if !ok {
+			continue
+		}
+
+		fn(p, par)
+	}
+}
+
+// assignTeams assigns every playing participant that is not yet in a team to the team with the least members,
+// so that the teams are balanced when the game starts.
+func (g *Game) assignTeams() {
+	if len(g.teams) == 0 {
+		return
+	}
+
+	for par := range g.PlayingParticipants() {
+		if _, ok := par.Team(); ok {
+			continue
+		}
+
+		var smallest *Team
+		for _, t := range g.teams {
+			if t.Full() {
+				continue
+			}
+			if smallest == nil || t.Len() < smallest.Len() {
+				smallest = t
+			}
+		}
+		if smallest == nil {
+			g.log.Warn("no team available for participant", "name", par.name)
+			continue
+		}
+		smallest.add(par)
+	}
+}
+
+// friendlyFireSuppressed returns whether damage dealt by the attacker to the victim passed should be cancelled
+// because both are in the same team and friendly fire is disabled.
+func (g *Game) friendlyFireSuppressed(attacker, victim world.Entity) bool {
+	if g.friendlyFire || attacker == nil || victim == nil {
+		return false
+	}
+	a, ok := attacker.(*player.Player)
+	if !ok {
+		return false
+	}
+	v, ok := victim.(*player.Player)
+	if !ok {
+		return false
+	}
+	aPar, ok := g.participants.Load(a.XUID())
+	if !ok {
+		return false
+	}
+	vPar, ok := g.participants.Load(v.XUID())
+	if !ok {
+		return false
+	}
+	return aPar.SameTeam(vPar)
+}
+
+// placeTogether moves all participants passed to the same team, choosing the team with the least members that has
+// room for all of them. It returns false if no such team exists.
+func (g *Game) placeTogether(pars []*Participant) bool {
+	smallest := g.teamFor(len(pars))
+	if smallest == nil {
+		return false
+	}
+	for _, par := range pars {
+		_ = g.SetTeam(par, smallest)
+	}
+	return true
+}
+
+// teamFor returns the team with the least members that has room for the amount of participants passed, or nil if
+// no team has enough room.
+func (g *Game) teamFor(n int) *Team {
+	var smallest *Team
+	for _, t := range g.teams {
+		if t.capacity > 0 && t.capacity-t.Len() < n {
+			continue
+		}
+		if smallest == nil || t.Len() < smallest.Len() {
+			smallest = t
+		}
+	}
+	return smallest
+}
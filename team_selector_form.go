@@ -0,0 +1,39 @@
+package game
+
+import (
+	"fmt"
+	form "github.com/akmalfairuz/ez-form"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+func sendTeamSelectorForm(g *Game, p *player.Player) {
+	teams := make([]*Team, 0, len(g.teams))
+	f := form.NewMenu("Select Team")
+	f.WithContent("Select a team to join:")
+	for t := range g.Teams() {
+		if t.Capacity() > 0 {
+			f.WithButton(fmt.Sprintf("%s\n%d/%d", t.FormattedName(), t.Len(), t.Capacity()))
+		} else {
+			f.WithButton(fmt.Sprintf("%s\n%d", t.FormattedName(), t.Len()))
+		}
+		teams = append(teams, t)
+	}
+	f.WithCallback(func(p *player.Player, result int) {
+		if g.closed.Load() || !g.State().Waiting() || !g.InGame(p) {
+			return
+		}
+		par, ok := g.ParticipantByXUID(p.XUID())
+		if !ok {
+			return
+		}
+
+		t := teams[result]
+		if err := g.SetTeam(par, t); err != nil {
+			p.Message(text.Colourf("<red>%s</red>", err))
+			return
+		}
+		p.Message(text.Colourf("<yellow>You joined team %s</yellow>", t.FormattedName()))
+	})
+	p.SendForm(f)
+}
@@ -16,7 +16,10 @@ func sendTeleporterForm(g *Game, p *player.Player) {
 		if !participant.state.Playing() {
 			continue
 		}
-		p2 := participant.MustPlayer(p.Tx())
+		p2, ok := participant.Player(p.Tx())
+		if !ok {
+			continue
+		}
 		m.WithButton(p2.Name(), fmt.Sprintf("https://player.venitymc.com/%s/avatar.png", strings.ToLower(p2.Name())))
 		xuids = append(xuids, p2.XUID())
 	}
@@ -33,7 +36,9 @@ func sendTeleporterForm(g *Game, p *player.Player) {
 			return
 		}
 
-		p.Teleport(targetPar.MustPlayer(p.Tx()).Position())
+		if target, ok := targetPar.Player(p.Tx()); ok {
+			p.Teleport(target.Position())
+		}
 	})
 
 	p.SendForm(m)
@@ -94,3 +94,40 @@ func copyFile(src, dst string, info os.FileInfo) error {
 
 	return nil
 }
+
+// textColour returns the text colour tag that most closely matches the item colour passed.
+func textColour(c item.Colour) string {
+	switch c {
+	case item.ColourWhite():
+		return "white"
+	case item.ColourOrange():
+		return "orange"
+	case item.ColourMagenta(), item.ColourPink():
+		return "purple"
+	case item.ColourLightBlue():
+		return "aqua"
+	case item.ColourYellow():
+		return "yellow"
+	case item.ColourLime():
+		return "green"
+	case item.ColourGrey():
+		return "dark-grey"
+	case item.ColourLightGrey():
+		return "grey"
+	case item.ColourCyan():
+		return "dark-aqua"
+	case item.ColourPurple():
+		return "dark-purple"
+	case item.ColourBlue():
+		return "blue"
+	case item.ColourBrown():
+		return "gold"
+	case item.ColourGreen():
+		return "dark-green"
+	case item.ColourRed():
+		return "red"
+	case item.ColourBlack():
+		return "black"
+	}
+	return "white"
+}
@@ -1,18 +1,31 @@
 package game
 
 import (
+	"fmt"
 	form "github.com/akmalfairuz/ez-form"
 	"github.com/df-mc/dragonfly/server/player"
+	"github.com/sandertv/gophertunnel/minecraft/text"
 )
 
 func sendVoteMapForm(g *Game, p *player.Player) {
+	par, ok := g.ParticipantByXUID(p.XUID())
+	if !ok {
+		return
+	}
+
+	ballot := g.Ballot()
+	votes := g.Votes()
 	f := form.NewMenu("Vote Map")
 	f.WithContent("Select a map to vote:")
-	for _, m := range g.availableMaps {
-		f.WithButton(m.Name)
+	for _, m := range ballot {
+		if par.vote == m {
+			f.WithButton(text.Colourf("<dark-green>%s</dark-green>\n<green>%d vote(s) - your vote</green>", m.Title(), votes[m]), m.Icon)
+			continue
+		}
+		f.WithButton(fmt.Sprintf("%s\n%d vote(s)", m.Title(), votes[m]), m.Icon)
 	}
 	f.WithCallback(func(p *player.Player, result int) {
-		if g.closed.Load() || !g.InGame(p) {
+		if g.closed.Load() || !g.State().Waiting() || g.mapLoaded || g.mapLoading || !g.InGame(p) {
 			return
 		}
 		par, ok := g.ParticipantByXUID(p.XUID())
@@ -20,7 +33,12 @@ func sendVoteMapForm(g *Game, p *player.Player) {
 			return
 		}
 
-		par.voteMapIndex = &result
+		m := ballot[result]
+		if par.vote == m {
+			return
+		}
+		par.vote = m
+		g.Messagef(p.Tx(), "<yellow>%s voted for <aqua>%s</aqua> (%d vote(s))</yellow>", p.Name(), m.Title(), g.Votes()[m])
 	})
 	p.SendForm(f)
 }
@@ -0,0 +1,243 @@
+package game
+
+import (
+	"fmt"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"time"
+)
+
+// WaitingRoom holds the waiting-room features of a lobby map. A map is a lobby map if its config.yml has a lobby
+// section. Lobby maps are used as the waiting world of games through Config.LobbyMap and are never played on.
+type WaitingRoom struct {
+	// Spawn is the position that participants are teleported to when they join. If nil, the spawn of the world
+	// is used.
+	Spawn *Spawn
+	// VoidY is the height below which participants are teleported back to their last checkpoint or the spawn.
+	VoidY float64
+	// Parkours are the parkour courses of the lobby.
+	Parkours []Parkour
+	// PracticeAreas are the areas in which participants are able to fight each other while waiting. Participants
+	// that would die in a practice area are teleported back to the spawn instead.
+	PracticeAreas []Area
+}
+
+// Parkour is a parkour course in a lobby map.
+type Parkour struct {
+	Name string
+	// Start is the block that starts the course when stepped on, Finish the block that finishes it.
+	Start, Finish cube.Pos
+	// Checkpoints are the blocks that participants are teleported back to when they fall into the void after
+	// reaching them, in the order they are reached.
+	Checkpoints []cube.Pos
+}
+
+// Area is a cuboid area between two corners.
+type Area struct {
+	Min, Max mgl64.Vec3
+}
+
+// Contains returns whether the position passed is within the area.
+func (a Area) Contains(pos mgl64.Vec3) bool {
+	return pos.X() >= a.Min.X() && pos.X() <= a.Max.X() &&
+		pos.Y() >= a.Min.Y() && pos.Y() <= a.Max.Y() &&
+		pos.Z() >= a.Min.Z() && pos.Z() <= a.Max.Z()
+}
+
+// waitingRoomConfig is the representation of a WaitingRoom in the config.yml of a map.
+type waitingRoomConfig struct {
+	Spawn    *spawnConfig `yaml:"spawn"`
+	VoidY    *float64     `yaml:"void_y"`
+	Parkours []struct {
+		Name        string      `yaml:"name"`
+		Start       posConfig   `yaml:"start"`
+		Finish      posConfig   `yaml:"finish"`
+		Checkpoints []posConfig `yaml:"checkpoints"`
+	} `yaml:"parkours"`
+	PracticeAreas []struct {
+		From posConfig `yaml:"from"`
+		To   posConfig `yaml:"to"`
+	} `yaml:"practice_areas"`
+}
+
+// posConfig is the representation of a block position in the config.yml of a map.
+type posConfig struct {
+	X int `yaml:"x"`
+	Y int `yaml:"y"`
+	Z int `yaml:"z"`
+}
+
+// pos converts the position config to a cube.Pos.
+func (c posConfig) pos() cube.Pos {
+	return cube.Pos{c.X, c.Y, c.Z}
+}
+
+// waitingRoom converts the config to a WaitingRoom.
+func (c waitingRoomConfig) waitingRoom() (*WaitingRoom, error) {
+	r := &WaitingRoom{VoidY: float64(world.Overworld.Range().Min())}
+	if c.Spawn != nil {
+		s := c.Spawn.spawn()
+		r.Spawn = &s
+	}
+	if c.VoidY != nil {
+		r.VoidY = *c.VoidY
+	}
+	for _, p := range c.Parkours {
+		if p.Start == p.Finish {
+			return nil, fmt.Errorf("parkour %s: start and finish must be different blocks", p.Name)
+		}
+		course := Parkour{Name: p.Name, Start: p.Start.pos(), Finish: p.Finish.pos()}
+		for _, cp := range p.Checkpoints {
+			course.Checkpoints = append(course.Checkpoints, cp.pos())
+		}
+		r.Parkours = append(r.Parkours, course)
+	}
+	for _, a := range c.PracticeAreas {
+		from, to := a.From.pos().Vec3(), a.To.pos().Add(cube.Pos{1, 1, 1}).Vec3()
+		r.PracticeAreas = append(r.PracticeAreas, Area{
+			Min: mgl64.Vec3{min(from.X(), to.X()), min(from.Y(), to.Y()), min(from.Z(), to.Z())},
+			Max: mgl64.Vec3{max(from.X(), to.X()), max(from.Y(), to.Y()), max(from.Z(), to.Z())},
+		})
+	}
+	return r, nil
+}
+
+// inPracticeArea returns whether the position passed is within one of the practice areas of the room.
+func (r *WaitingRoom) inPracticeArea(pos mgl64.Vec3) bool {
+	for _, a := range r.PracticeAreas {
+		if a.Contains(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// parkourRun is the progress of a participant on a parkour course.
+type parkourRun struct {
+	course     *Parkour
+	checkpoint int
+	started    time.Time
+}
+
+// WaitingRoom returns the waiting room of the lobby map that the game uses as its waiting world, if any.
+func (g *Game) WaitingRoom() (*WaitingRoom, bool) {
+	if !g.ownWaiting || g.waitingMap == nil || g.waitingMap.WaitingRoom == nil {
+		return nil, false
+	}
+	return g.waitingMap.WaitingRoom, true
+}
+
+// waitingSpawn teleports the player passed to the spawn of the waiting world of the game.
+func (g *Game) waitingSpawn(p *player.Player) {
+	if room, ok := g.WaitingRoom(); ok && room.Spawn != nil {
+		room.Spawn.Teleport(p)
+		return
+	}
+	p.Teleport(g.waitingWorld().Spawn().Vec3Middle())
+}
+
+// handleWaitingMove handles the movement of a participant to the position passed while the game is waiting. It
+// returns true if the movement should be cancelled.
+func (g *Game) handleWaitingMove(p *player.Player, pos mgl64.Vec3) bool {
+	par, ok := g.participants.Load(p.XUID())
+	if !ok {
+		return false
+	}
+	room, hasRoom := g.WaitingRoom()
+
+	voidY := float64(p.Tx().Range().Min())
+	if hasRoom {
+		voidY = room.VoidY
+	}
+	if pos.Y() < voidY {
+		if par.parkour != nil && par.parkour.checkpoint >= 0 {
+			p.Teleport(par.parkour.course.Checkpoints[par.parkour.checkpoint].Vec3Middle().Add(mgl64.Vec3{0, 0.5}))
+		} else {
+			par.parkour = nil
+			g.waitingSpawn(p)
+		}
+		return true
+	}
+	if hasRoom {
+		g.trackParkour(p, par, room, cube.PosFromVec3(pos).Side(cube.FaceDown))
+	}
+	return false
+}
+
+// trackParkour updates the parkour progress of the participant passed, which is standing on the block passed.
+func (g *Game) trackParkour(p *player.Player, par *Participant, room *WaitingRoom, standingOn cube.Pos) {
+	for i := range room.Parkours {
+		course := &room.Parkours[i]
+		if standingOn == course.Start && (par.parkour == nil || par.parkour.course != course) {
+			par.parkour = &parkourRun{course: course, checkpoint: -1, started: time.Now()}
+			p.Message(text.Colourf("<green>Started the %s parkour!</green>", course.Name))
+			return
+		}
+	}
+
+	run := par.parkour
+	if run == nil {
+		return
+	}
+	if standingOn == run.course.Finish {
+		par.parkour = nil
+		p.Message(text.Colourf("<green>Finished the %s parkour in <yellow>%.2f</yellow> seconds!</green>", run.course.Name, time.Since(run.started).Seconds()))
+		return
+	}
+	for i := run.checkpoint + 1; i < len(run.course.Checkpoints); i++ {
+		if standingOn == run.course.Checkpoints[i] {
+			run.checkpoint = i
+			p.Message(text.Colourf("<yellow>Reached checkpoint %d of the %s parkour.</yellow>", i+1, run.course.Name))
+			return
+		}
+	}
+}
+
+// practiceHit returns whether the attacker passed is able to hit the victim passed in a practice area while the
+// game is waiting.
+func (g *Game) practiceHit(attacker world.Entity, victim *player.Player) bool {
+	room, ok := g.WaitingRoom()
+	if !ok {
+		return false
+	}
+	p, ok := attacker.(*player.Player)
+	if !ok || !g.InGame(p) {
+		return false
+	}
+	return room.inPracticeArea(p.Position()) && room.inPracticeArea(victim.Position())
+}
+
+// practiceHurt handles damage dealt to a participant in a practice area while the game is waiting. It returns
+// false if the damage is not dealt in a practice area. Participants that would die are teleported back to the
+// spawn instead.
+func (g *Game) practiceHurt(ctx *player.Context, damage float64, src world.DamageSource) bool {
+	attack, ok := src.(entity.AttackDamageSource)
+	if !ok || !g.practiceHit(attack.Attacker, ctx.Val()) {
+		return false
+	}
+	p := ctx.Val()
+	if p.Health()-damage > 0 {
+		return true
+	}
+	ctx.Cancel()
+	p.Heal(p.MaxHealth(), ResetPlayerHealSource{})
+	g.waitingSpawn(p)
+	p.Message(text.Colourf("<red>You were defeated in the practice area.</red>"))
+	return true
+}
+
+// lobbyMap returns the lobby map with the name passed from the registry of the game.
+func (g *Game) lobbyMap(name string) (*Map, error) {
+	m, ok := g.registry.Map(name)
+	if !ok {
+		return nil, fmt.Errorf("lobby map %s not found", name)
+	}
+	if m.WaitingRoom == nil {
+		return nil, fmt.Errorf("map %s is not a lobby map", name)
+	}
+	return m, nil
+}
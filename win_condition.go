@@ -0,0 +1,222 @@
+package game
+
+import (
+	"cmp"
+	"github.com/df-mc/dragonfly/server/world"
+	"iter"
+	"slices"
+	"time"
+)
+
+// WinCondition decides whether a game ended and with which result. Win conditions of a game are evaluated after
+// participants are eliminated, leave the game or score points, and every second while the game is playing.
+type WinCondition interface {
+	// Check returns the result that the game passed ends with, and true, if the condition is met.
+	Check(g *Game) (Result, bool)
+}
+
+// WinConditionFunc is a WinCondition implemented by a function, for custom conditions.
+type WinConditionFunc func(g *Game) (Result, bool)
+
+// Check ...
+func (f WinConditionFunc) Check(g *Game) (Result, bool) {
+	return f(g)
+}
+
+// LastPlayerStanding returns a WinCondition that is met when at most one participant is still playing. The
+// remaining participant wins, and the others are placed in the reverse order that they were eliminated in.
+// Participants that disconnected but may still rejoin count as playing.
+func LastPlayerStanding() WinCondition {
+	return WinConditionFunc(func(g *Game) (Result, bool) {
+		standing := slices.Collect(g.standingParticipants())
+		if len(standing) > 1 {
+			return Result{}, false
+		}
+		return Result{
+			Reason:     EndReasonElimination,
+			Winners:    standing,
+			Placements: g.eliminationPlacements(standing),
+		}, true
+	})
+}
+
+// LastTeamStanding returns a WinCondition that is met when at most one team still has playing members. The
+// remaining team wins. It is never met in games without teams.
+func LastTeamStanding() WinCondition {
+	return WinConditionFunc(func(g *Game) (Result, bool) {
+		if len(g.teams) == 0 {
+			return Result{}, false
+		}
+		var standing []*Team
+		for _, t := range g.teams {
+			if t.standing() {
+				standing = append(standing, t)
+			}
+		}
+		if len(standing) > 1 {
+			return Result{}, false
+		}
+		res := Result{Reason: EndReasonElimination}
+		if len(standing) == 1 {
+			res.WinningTeam = standing[0]
+		}
+		return res, true
+	})
+}
+
+// FirstToScore returns a WinCondition that is met when a team, or a participant in games without teams, reaches
+// the score passed. The team or participant with the highest score wins.
+func FirstToScore(score int) WinCondition {
+	return WinConditionFunc(func(g *Game) (Result, bool) {
+		res, top := g.scoreResult()
+		if top < score {
+			return Result{}, false
+		}
+		res.Reason = EndReasonScore
+		return res, true
+	})
+}
+
+// HighestScore returns a WinCondition that is met when the game has been playing for the duration passed. The
+// team, or participant in games without teams, with the highest score wins. A tie for the highest score is a
+// draw. If the duration is equal to Lifecycle.MaxDuration, the condition is met instead of the game ending in a
+// draw.
+func HighestScore(limit time.Duration) WinCondition {
+	return WinConditionFunc(func(g *Game) (Result, bool) {
+		if g.Elapsed() < limit {
+			return Result{}, false
+		}
+		res, _ := g.scoreResult()
+		res.Reason = EndReasonTimeout
+		return res, true
+	})
+}
+
+// Score returns the score of the participant.
+func (par *Participant) Score() int {
+	return par.score
+}
+
+// AddScore adds the points passed to the score of the participant passed and the score of its team, after which
+// the win conditions of the game are evaluated. The points may be negative.
+func (g *Game) AddScore(tx *world.Tx, par *Participant, points int) {
+	if !g.ValidTx(tx) || !g.State().Playing() {
+		return
+	}
+	par.score += points
+	if t, ok := par.Team(); ok {
+		t.score += points
+	}
+	g.checkWin(tx)
+}
+
+// checkWin evaluates the win conditions of the game in order and ends the game with the result of the first
+// condition that is met. A game in which no participant is left playing is always ended. It returns true if the
+// game ended.
+func (g *Game) checkWin(tx *world.Tx) bool {
+	if g.aborting || !g.ValidTx(tx) || !g.State().Playing() {
+		return false
+	}
+	for _, c := range g.winConditions {
+		var (
+			res Result
+			ok  bool
+		)
+		g.callImpl(tx, func() {
+			res, ok = c.Check(g)
+		})
+		if g.closed.Load() {
+			return true
+		}
+		if ok {
+			g.End(tx, res)
+			return true
+		}
+	}
+	for range g.standingParticipants() {
+		return false
+	}
+	g.End(tx, Result{Reason: EndReasonForfeit})
+	return true
+}
+
+// eliminate records that the participant passed stopped playing, so that it may be placed by
+// LastPlayerStanding.
+func (g *Game) eliminate(par *Participant) {
+	if g.State().Playing() && !slices.Contains(g.eliminated, par) {
+		g.eliminated = append(g.eliminated, par)
+	}
+}
+
+// eliminationPlacements places the winners passed first, and the eliminated participants in the reverse order
+// that they were eliminated in.
+func (g *Game) eliminationPlacements(winners []*Participant) map[string]int {
+	placements := make(map[string]int, len(winners)+len(g.eliminated))
+	for _, par := range winners {
+		placements[par.xuid] = 1
+	}
+	for i, par := range g.eliminated {
+		placements[par.xuid] = 1 + len(winners) + len(g.eliminated) - 1 - i
+	}
+	return placements
+}
+
+// scoreResult returns a result in which the team, or participant in games without teams, with the highest score
+// wins, along with that score. A tie for the highest score is a draw.
+func (g *Game) scoreResult() (Result, int) {
+	if len(g.teams) > 0 {
+		teams := slices.SortedFunc(slices.Values(g.teams), func(a, b *Team) int {
+			return cmp.Compare(b.score, a.score)
+		})
+		if len(teams) > 1 && teams[0].score == teams[1].score {
+			return Result{}, teams[0].score
+		}
+		return Result{WinningTeam: teams[0]}, teams[0].score
+	}
+
+	pars := slices.SortedFunc(g.standingParticipants(), func(a, b *Participant) int {
+		return cmp.Compare(b.score, a.score)
+	})
+	if len(pars) == 0 {
+		return Result{}, 0
+	}
+	placements := make(map[string]int, len(pars))
+	for i, par := range pars {
+		placements[par.xuid] = i + 1
+		if i > 0 && par.score == pars[i-1].score {
+			placements[par.xuid] = placements[pars[i-1].xuid]
+		}
+	}
+	res := Result{Placements: placements}
+	if len(pars) == 1 || pars[0].score != pars[1].score {
+		res.Winners = []*Participant{pars[0]}
+	}
+	return res, pars[0].score
+}
+
+// standingParticipants returns the participants that are still in the game: playing, or disconnected but able to
+// rejoin.
+func (g *Game) standingParticipants() iter.Seq[*Participant] {
+	return func(yield func(*Participant) bool) {
+		for _, par := range g.participants.Map() {
+			if par.standing() && !yield(par) {
+				return
+			}
+		}
+	}
+}
+
+// standing returns whether the participant is still in the game: playing, or disconnected but able to rejoin.
+func (par *Participant) standing() bool {
+	return par.state.Playing() || par.state.Disconnected()
+}
+
+// standing returns whether any member of the team is still in the game.
+func (t *Team) standing() bool {
+	for _, par := range t.members.Map() {
+		if par.standing() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+package game
+
+import (
+	"fmt"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+	"github.com/df-mc/goleveldb/leveldb"
+	"github.com/google/uuid"
+	"os"
+	"sync"
+)
+
+// WorldInstancing specifies how the world of a map is instanced for every game that is played on it.
+type WorldInstancing uint8
+
+const (
+	// InstanceMemory loads the world of a map into memory once and serves every game a private in-memory copy of
+	// it. Chunks are read from the template until they are changed, and nothing is written to disk. If the world
+	// of a map could not be loaded into memory, InstanceCopy is used instead.
+	InstanceMemory WorldInstancing = iota
+	// InstanceCopy copies the world directory of a map for every game and opens the copy from disk.
+	InstanceCopy
+)
+
+// columnKey is the position and dimension of a column in a world.
+type columnKey struct {
+	pos world.ChunkPos
+	dim world.Dimension
+}
+
+// templateColumn is a column of a worldTemplate. The chunk is kept encoded so that every instance decodes its own
+// copy of it.
+type templateColumn struct {
+	data            chunk.SerialisedData
+	entities        []chunk.Entity
+	blockEntities   []chunk.BlockEntity
+	tick            int64
+	scheduledBlocks []chunk.ScheduledBlockUpdate
+}
+
+// worldTemplate is the world of a map loaded into memory.
+type worldTemplate struct {
+	settings *world.Settings
+	columns  map[columnKey]templateColumn
+}
+
+// loadWorldTemplate loads the world at the path passed into memory. The world is read from a temporary copy, so
+// that the world of the map itself is never modified.
+func loadWorldTemplate(path string) (*worldTemplate, error) {
+	tmp, err := os.MkdirTemp("", "df-game-template-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	if err := copyDir(path, tmp); err != nil {
+		return nil, fmt.Errorf("failed to copy world: %w", err)
+	}
+
+	db, err := mcdb.Open(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open world: %w", err)
+	}
+	defer db.Close()
+
+	t := &worldTemplate{
+		settings: db.Settings(),
+		columns:  make(map[columnKey]templateColumn),
+	}
+	it := db.NewColumnIterator(nil)
+	defer it.Release()
+	for it.Next() {
+		col := it.Column()
+		t.columns[columnKey{pos: it.Position(), dim: it.Dimension()}] = templateColumn{
+			data:            chunk.Encode(col.Chunk, chunk.DiskEncoding),
+			entities:        col.Entities,
+			blockEntities:   col.BlockEntities,
+			tick:            col.Tick,
+			scheduledBlocks: col.ScheduledBlocks,
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, fmt.Errorf("failed to read world: %w", err)
+	}
+	return t, nil
+}
+
+// column returns a new copy of the column at the key passed.
+func (t *worldTemplate) column(k columnKey) (*chunk.Column, error) {
+	tc, ok := t.columns[k]
+	if !ok {
+		return nil, leveldb.ErrNotFound
+	}
+	c, err := chunk.DiskDecode(tc.data, k.dim.Range())
+	if err != nil {
+		return nil, err
+	}
+	col := &chunk.Column{
+		Chunk:           c,
+		Entities:        make([]chunk.Entity, len(tc.entities)),
+		BlockEntities:   make([]chunk.BlockEntity, len(tc.blockEntities)),
+		Tick:            tc.tick,
+		ScheduledBlocks: append([]chunk.ScheduledBlockUpdate(nil), tc.scheduledBlocks...),
+	}
+	for i, e := range tc.entities {
+		col.Entities[i] = chunk.Entity{ID: e.ID, Data: cloneNBT(e.Data).(map[string]any)}
+	}
+	for i, be := range tc.blockEntities {
+		col.BlockEntities[i] = chunk.BlockEntity{Pos: be.Pos, Data: cloneNBT(be.Data).(map[string]any)}
+	}
+	return col, nil
+}
+
+// instance creates a new world.Provider that reads from the template and keeps all changes in memory.
+func (t *worldTemplate) instance() world.Provider {
+	s := t.settings
+	return &memoryProvider{
+		t: t,
+		set: &world.Settings{
+			Name:            s.Name,
+			Spawn:           s.Spawn,
+			Time:            s.Time,
+			TimeCycle:       s.TimeCycle,
+			RainTime:        s.RainTime,
+			Raining:         s.Raining,
+			ThunderTime:     s.ThunderTime,
+			Thundering:      s.Thundering,
+			WeatherCycle:    s.WeatherCycle,
+			CurrentTick:     s.CurrentTick,
+			DefaultGameMode: s.DefaultGameMode,
+			Difficulty:      s.Difficulty,
+			TickRange:       s.TickRange,
+		},
+		columns: make(map[columnKey]*chunk.Column),
+		spawns:  make(map[uuid.UUID]cube.Pos),
+	}
+}
+
+// cloneNBT returns a deep copy of the NBT value passed.
+func cloneNBT(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, val := range v {
+			m[k] = cloneNBT(val)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, val := range v {
+			s[i] = cloneNBT(val)
+		}
+		return s
+	}
+	return v
+}
+
+// memoryProvider is a world.Provider of a single game that serves the columns of a worldTemplate. Columns stored
+// by the world are kept in memory and take precedence over the template.
+type memoryProvider struct {
+	t   *worldTemplate
+	set *world.Settings
+
+	mu      sync.Mutex
+	columns map[columnKey]*chunk.Column
+	spawns  map[uuid.UUID]cube.Pos
+}
+
+// Settings ...
+func (p *memoryProvider) Settings() *world.Settings {
+	return p.set
+}
+
+// SaveSettings ...
+func (p *memoryProvider) SaveSettings(*world.Settings) {}
+
+// LoadPlayerSpawnPosition ...
+func (p *memoryProvider) LoadPlayerSpawnPosition(id uuid.UUID) (cube.Pos, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pos, ok := p.spawns[id]
+	return pos, ok, nil
+}
+
+// SavePlayerSpawnPosition ...
+func (p *memoryProvider) SavePlayerSpawnPosition(id uuid.UUID, pos cube.Pos) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.spawns[id] = pos
+	return nil
+}
+
+// LoadColumn ...
+func (p *memoryProvider) LoadColumn(pos world.ChunkPos, dim world.Dimension) (*chunk.Column, error) {
+	k := columnKey{pos: pos, dim: dim}
+	p.mu.Lock()
+	col, ok := p.columns[k]
+	p.mu.Unlock()
+	if ok {
+		return col, nil
+	}
+	return p.t.column(k)
+}
+
+// StoreColumn ...
+func (p *memoryProvider) StoreColumn(pos world.ChunkPos, dim world.Dimension, col *chunk.Column) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.columns[columnKey{pos: pos, dim: dim}] = col
+	return nil
+}
+
+// Close ...
+func (p *memoryProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	clear(p.columns)
+	return nil
+}
+
+// PreloadWorld loads the world of the map into memory, so that the first game played on it with InstanceMemory
+// does not have to wait for it. The world is only loaded once; later calls return the result of the first.
+func (m *Map) PreloadWorld() error {
+	_, err := m.worldTemplate()
+	return err
+}
+
+// worldTemplate returns the world of the map loaded into memory, loading it if it was not loaded yet.
+func (m *Map) worldTemplate() (*worldTemplate, error) {
+	m.templateOnce.Do(func() {
+		m.template, m.templateErr = loadWorldTemplate(m.WorldPath)
+	})
+	return m.template, m.templateErr
+}
+
+// openWorld opens a private instance of the world of the map passed using the WorldInstancing of the game.
+func (g *Game) openWorld(m *Map) (world.Provider, error) {
+	if g.instancing == InstanceMemory {
+		t, err := m.worldTemplate()
+		if err == nil {
+			return t.instance(), nil
+		}
+		g.log.Error("failed to load world into memory, copying it instead", "map", m.Name, "error", err)
+	}
+
+	if err := m.CopyWorldTo(g.wPath); err != nil {
+		return nil, fmt.Errorf("failed to copy map world: %w", err)
+	}
+	prov, err := mcdb.Open(g.wPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open world: %w", err)
+	}
+	return prov, nil
+}